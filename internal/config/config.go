@@ -21,12 +21,13 @@ import (
 	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/mdlayher/ndp"
 )
 
 //go:generate embed file -var Default --source default.toml
 
 // Default is the toml representation of the default configuration.
-var Default = "# CoreRAD vALPHA configuration file\n\n# All duration values are specified in Go time.ParseDuration format:\n# https://golang.org/pkg/time/#ParseDuration.\n\n# Interfaces which will be used to serve IPv6 NDP router advertisements.\n[[interfaces]]\nname = \"eth0\"\n\n# AdvSendAdvertisements: indicates whether or not this interface will send\n# periodic router advertisements and respond to router solicitations.\nsend_advertisements = true\n\n# MaxRtrAdvInterval: the maximum time between sending unsolicited multicast\n# router advertisements. Must be between 4 and 1800 seconds.\nmax_interval = \"600s\"\n\n# MinRtrAdvInterval: the minimum time between sending unsolicited multicast\n# router advertisements. Must be between 3 and (.75 * max_interval) seconds.\n# An empty string or the value \"auto\" will compute a sane default.\nmin_interval = \"auto\"\n\n# AdvManagedFlag: indicates if hosts should request address configuration from a\n# DHCPv6 server.\nmanaged = false\n\n# AdvOtherConfigFlag: indicates if additional configuration options are\n# available from a DHCPv6 server.\nother_config = false\n\n# AdvReachableTime: indicates how long a node should treat a neighbor as\n# reachable. 0 or empty string mean this value is unspecified by this router.\nreachable_time = \"0s\"\n\n# AdvRetransTimer: indicates how long a node should wait before retransmitting\n# neighbor solicitations. 0 or empty string mean this value is unspecified by\n# this router.\nretransmit_timer = \"0s\"\n\n# AdvCurHopLimit: indicates the value that should be placed in the Hop Limit\n# field in the IPv6 header. Must be between 0 and 255. 0 means this value\n# is unspecified by this router.\nhop_limit = 64\n\n# AdvDefaultLifetime: the value sent in the router lifetime field. Must be\n# 0 or between max_interval and 9000 seconds. An empty string is treated as 0,\n# or the value \"auto\" will compute a sane default.\ndefault_lifetime = \"auto\"\n\n  # Zero or more plugins may be specified to modify the behavior of the router\n  # advertisements produced by CoreRAD.\n\n  # \"prefix\" plugin: attaches a NDP Prefix Information option to the router\n  # advertisement.\n  [[interfaces.plugins]]\n  name = \"prefix\"\n  # Serve Prefix Information options for each IPv6 prefix on this interface\n  # configured with a /64 CIDR mask.\n  prefix = \"::/64\"\n  # Specifies on-link and autonomous address autoconfiguration (SLAAC) flags\n  # for this prefix. Both default to true.\n  on_link = true\n  autonomous = true\n  # Specifies the preferred and valid lifetimes for this prefix. The preferred\n  # lifetime must not exceed the valid lifetime. By default, the preferred\n  # lifetime is 7 days and the valid lifetime is 30 days. \"auto\" uses the\n  # defaults. \"infinite\" means this prefix should be used forever.\n  preferred_lifetime = \"5m\"\n  valid_lifetime = \"10m\"\n\n  # Alternatively, serve an explicit IPv6 prefix.\n  [[interfaces.plugins]]\n  name = \"prefix\"\n  prefix = \"2001:db8::/64\"\n\n  # \"rdnss\" plugin: attaches a NDP Recursive DNS Servers option to the router\n  # advertisement.\n  [[interfaces.plugins]]\n  name = \"rdnss\"\n  # The maximum time these RDNSS addresses may be used for name resolution.\n  # An empty string or 0 means these servers should no longer be used.\n  # \"auto\" will compute a sane default. \"infinite\" means these servers should\n  # be used forever.\n  lifetime = \"auto\"\n  servers = [\"2001:db8::1\", \"2001:db8::2\"]\n\n  # \"dnssl\" plugin: attaches a NDP DNS Search List option to the router\n  # advertisement.\n  [[interfaces.plugins]]\n  name = \"dnssl\"\n  # The maximum time these DNSSL domain names may be used for name resolution.\n  # An empty string or 0 means these search domains should no longer be used.\n  # \"auto\" will compute a sane default. \"infinite\" means these search domains\n  # should be used forever.\n  lifetime = \"auto\"\n  domain_names = [\"foo.example.com\"]\n\n  # \"mtu\" plugin: attaches a NDP MTU option to the router advertisement.\n  [[interfaces.plugins]]\n  name = \"mtu\"\n  mtu = 1500\n\n# Enable or disable the debug HTTP server for facilities such as Prometheus\n# metrics and pprof support.\n#\n# Warning: do not expose pprof on an untrusted network!\n[debug]\naddress = \"localhost:9430\"\nprometheus = true\npprof = false\n"
+var Default = "# CoreRAD vALPHA configuration file\n\n# All duration values are specified in Go time.ParseDuration format:\n# https://golang.org/pkg/time/#ParseDuration.\n\n# Interfaces which will be used to serve IPv6 NDP router advertisements.\n[[interfaces]]\nname = \"eth0\"\n\n# Wait for this interface to exist, be up, and have a usable IPv6 link-local\n# address before sending advertisements, rather than failing on startup. The\n# interface is monitored so advertisements stop when it goes away and resume\n# when it returns, which is useful for VLAN, bridge, or PPPoE interfaces.\n# Defaults to false.\nwait_for_interface = false\n\n# On startup, wait up to this long for the interface to have an IPv6\n# link-local address which has completed duplicate address detection, so\n# advertisements are not sent from a tentative address. If duplicate address\n# detection fails, an error is logged and CoreRAD keeps waiting for a usable\n# address. 0 uses any link-local address immediately. An empty string or the\n# value \"auto\" uses a default of 10 seconds.\nlink_local_timeout = \"auto\"\n\n# AdvSendAdvertisements: indicates whether or not this interface will send\n# periodic router advertisements and respond to router solicitations.\nsend_advertisements = true\n\n# MaxRtrAdvInterval: the maximum time between sending unsolicited multicast\n# router advertisements. Must be between 4 and 1800 seconds.\nmax_interval = \"600s\"\n\n# MinRtrAdvInterval: the minimum time between sending unsolicited multicast\n# router advertisements. Must be between 3 and (.75 * max_interval) seconds.\n# An empty string or the value \"auto\" will compute a sane default.\nmin_interval = \"auto\"\n\n# AdvIntervalOpt: indicates whether or not to include the Advertisement\n# Interval option described in RFC 6275 in router advertisements. The option\n# always carries the value of max_interval.\nadvertisement_interval_option = false\n\n# AdvManagedFlag: indicates if hosts should request address configuration from a\n# DHCPv6 server.\nmanaged = false\n\n# AdvOtherConfigFlag: indicates if additional configuration options are\n# available from a DHCPv6 server.\nother_config = false\n\n# AdvHomeAgentFlag: indicates that this router is also a Mobile IPv6 home\n# agent on this link.\nmobile_ipv6_home_agent = false\n\n# AdvDefaultPreference: the preference of this router as a default router\n# relative to other routers on this link: \"low\", \"medium\", or \"high\". Defaults\n# to \"medium\", and is always sent as \"medium\" when default_lifetime is 0.\npreference = \"medium\"\n\n# Indicates that this router is proxying Neighbor Discovery messages, as\n# described in RFC 4389.\nneighbor_discovery_proxy = false\n\n# AdvReachableTime: indicates how long a node should treat a neighbor as\n# reachable. 0 or empty string mean this value is unspecified by this router.\nreachable_time = \"0s\"\n\n# AdvRetransTimer: indicates how long a node should wait before retransmitting\n# neighbor solicitations. 0 or empty string mean this value is unspecified by\n# this router.\nretransmit_timer = \"0s\"\n\n# AdvCurHopLimit: indicates the value that should be placed in the Hop Limit\n# field in the IPv6 header. Must be between 0 and 255. 0 means this value\n# is unspecified by this router.\nhop_limit = 64\n\n# AdvDefaultLifetime: the value sent in the router lifetime field. Must be\n# 0 or between max_interval and 9000 seconds. An empty string is treated as 0,\n# or the value \"auto\" will compute a sane default.\ndefault_lifetime = \"auto\"\n\n# When CoreRAD shuts down, its final router advertisements deprecate all\n# prefixes by setting their preferred lifetimes to 0, and set the lifetimes of\n# routes, RDNSS servers, and DNSSL domains to 0, so hosts stop using this\n# router's configuration before it is decommissioned. Defaults to false.\nshutdown_deprecate_prefixes = false\n\n# Only valid when shutdown_deprecate_prefixes is true: if set, also reduce the\n# valid lifetimes of prefixes to this value on shutdown. Note that hosts will\n# not reduce the valid lifetime of an address below 2 hours, per RFC 4862,\n# section 5.5.3. An empty string or 0 leaves valid lifetimes unchanged.\n# shutdown_valid_lifetime = \"2h\"\n\n# When CoreRAD shuts down, it sends up to 3 final router advertisements spaced\n# 3 seconds apart, as described in RFC 4861, section 6.2.5. This timeout bounds\n# the time spent doing so. 0 sends a single final router advertisement. An\n# empty string or the value \"auto\" uses a default of 10 seconds.\nshutdown_timeout = \"auto\"\n\n# Rate limits for router advertisements sent in response to router\n# solicitations, so a misbehaving host cannot cause a flood of advertisements.\n# Each host, identified by its IPv6 address and link-layer address, may receive\n# up to solicitation_burst advertisements at once, and earns another every\n# solicitation_interval. solicitation_global_interval limits advertisements to\n# all hosts. An empty string uses the defaults shown here, and \"0s\" disables a\n# limit. solicitation_burst must be between 1 and 1000.\nsolicitation_interval = \"1s\"\nsolicitation_burst = 3\nsolicitation_global_interval = \"10ms\"\n\n  # Optional: IPv6 sysctls for this interface, which are set when CoreRAD\n  # starts and restored to their previous values when it stops. Each change is\n  # logged. Keys which are not set are left unchanged. Only supported on Linux.\n  # [interfaces.sysctl]\n  # # Whether the interface forwards IPv6 packets, which must be true to\n  # # advertise a non-zero default_lifetime.\n  # forwarding = true\n  # # Whether the interface accepts router advertisements: 0 to never accept\n  # # them, 1 to accept them when not forwarding, or 2 to always accept them.\n  # accept_ra = 0\n  # # Whether a default route is learned from accepted router advertisements.\n  # accept_ra_defrtr = false\n  # # The IPv6 MTU of the interface. Must be between 1280 and 65535.\n  # mtu = 1500\n\n  # Zero or more plugins may be specified to modify the behavior of the router\n  # advertisements produced by CoreRAD.\n\n  # \"prefix\" plugin: attaches a NDP Prefix Information option to the router\n  # advertisement.\n  [[interfaces.plugins]]\n  name = \"prefix\"\n  # Serve Prefix Information options for each IPv6 prefix on this interface\n  # configured with a /64 CIDR mask.\n  prefix = \"::/64\"\n  # Specifies on-link and autonomous address autoconfiguration (SLAAC) flags\n  # for this prefix. Both default to true.\n  on_link = true\n  autonomous = true\n  # Specifies the preferred and valid lifetimes for this prefix. The preferred\n  # lifetime must not exceed the valid lifetime. By default, the preferred\n  # lifetime is 7 days and the valid lifetime is 30 days. \"auto\" uses the\n  # defaults. \"infinite\" means this prefix should be used forever.\n  preferred_lifetime = \"5m\"\n  valid_lifetime = \"10m\"\n  # AdvPreferredLifetime and AdvValidLifetime decrement in real time from\n  # when CoreRAD starts, rather than being reset in each advertisement, as\n  # described in RFC 4861, section 6.2.1. The prefix is no longer served once\n  # its valid lifetime reaches 0. Defaults to false.\n  decrement_lifetimes = false\n  # When a prefix served by \"::/N\" disappears from this interface, continue to\n  # serve it with a preferred lifetime of 0 and a decreasing valid lifetime for\n  # this period so hosts stop using it, as described in RFC 8978. Must not\n  # exceed valid_lifetime. 0 disables deprecation. \"auto\" uses the lesser of\n  # 2 hours and valid_lifetime.\n  deprecation_period = \"auto\"\n  # AdvRouterAddr: advertise this router's full address within the prefix\n  # rather than the prefix itself, as required for Mobile IPv6 home agents\n  # described in RFC 6275. Defaults to false.\n  router_address = false\n  # Select which of the interface's prefixes are served by \"::/N\". A static\n  # prefix must also satisfy these filters. \"scope\" is \"any\", \"unique-local\",\n  # or \"global\", and defaults to \"any\". If \"include\" is set, a prefix must be\n  # within one of its prefixes. A prefix within any of the \"exclude\" prefixes\n  # is never served. Addresses which are tentative or deprecated are always\n  # ignored.\n  # scope = \"global\"\n  # include = [\"2001:db8::/32\"]\n  # exclude = [\"2001:db8:ffff::/48\"]\n\n  # Alternatively, serve an explicit IPv6 prefix.\n  [[interfaces.plugins]]\n  name = \"prefix\"\n  prefix = \"2001:db8::/64\"\n\n  # Alternatively, serve a subnet of a prefix delegated to this router, such as\n  # by a DHCPv6 Prefix Delegation client. The file is read again whenever it\n  # changes, and must contain a JSON object such as:\n  #\n  #   {\"prefix\": \"2001:db8::/56\", \"preferred_lifetime\": 3600, \"valid_lifetime\": 7200}\n  #\n  # Lifetimes are specified in seconds and are optional. If present, they are\n  # served instead of preferred_lifetime and valid_lifetime, and count down\n  # from the time the file was last modified. If the file is removed, the\n  # prefix is no longer served. If it cannot be read or parsed, the last prefix\n  # read is served.\n  # [[interfaces.plugins]]\n  # name = \"prefix\"\n  # # The length of the subnet served on this interface, which must be of the\n  # # form \"::/N\".\n  # prefix = \"::/64\"\n  # delegated_prefix_file = \"/run/corerad/delegated-prefix.json\"\n  # # The subnet number of the delegated prefix to serve. Defaults to 0.\n  # subnet = 1\n\n  # \"rdnss\" plugin: attaches a NDP Recursive DNS Servers option to the router\n  # advertisement.\n  [[interfaces.plugins]]\n  name = \"rdnss\"\n  # The maximum time these RDNSS addresses may be used for name resolution.\n  # An empty string or 0 means these servers should no longer be used.\n  # \"auto\" will compute a sane default. \"infinite\" means these servers should\n  # be used forever.\n  lifetime = \"auto\"\n  # Decrement lifetime in real time from when CoreRAD starts rather than\n  # resetting it in each advertisement, and stop serving these servers once it\n  # reaches 0. Requires an explicit lifetime. Defaults to false.\n  decrement_lifetimes = false\n  # The IPv6 addresses of recursive DNS servers. \"::\" serves each of the IPv6\n  # addresses on this interface within scope. \"auto\" uses the IPv6 nameservers\n  # in resolv_conf, and updates them when that file changes. Link-local and\n  # zoned nameservers are ignored, and the last values read are kept if the\n  # file cannot be read.\n  servers = [\"2001:db8::1\", \"2001:db8::2\"]\n  # Only valid when servers contains \"::\": selects the interface addresses to\n  # serve: \"any\", \"link-local\", \"unique-local\", or \"global\". Defaults to \"any\".\n  # scope = \"any\"\n  # Only valid when servers is \"auto\": the path to a resolv.conf file.\n  # Defaults to \"/etc/resolv.conf\".\n  # resolv_conf = \"/etc/resolv.conf\"\n\n  # \"dnssl\" plugin: attaches a NDP DNS Search List option to the router\n  # advertisement.\n  [[interfaces.plugins]]\n  name = \"dnssl\"\n  # The maximum time these DNSSL domain names may be used for name resolution.\n  # An empty string or 0 means these search domains should no longer be used.\n  # \"auto\" will compute a sane default. \"infinite\" means these search domains\n  # should be used forever.\n  lifetime = \"auto\"\n  # Decrement lifetime in real time from when CoreRAD starts rather than\n  # resetting it in each advertisement, and stop serving these search domains\n  # once it reaches 0. Requires an explicit lifetime. Defaults to false.\n  decrement_lifetimes = false\n  # DNS search domains. \"auto\" uses the search domains in resolv_conf, and\n  # updates them when that file changes. The last values read are kept if the\n  # file cannot be read.\n  domain_names = [\"foo.example.com\"]\n  # Only valid when domain_names is \"auto\": the path to a resolv.conf file.\n  # Defaults to \"/etc/resolv.conf\".\n  # resolv_conf = \"/etc/resolv.conf\"\n\n  # \"dnr\" plugin: attaches a NDP Encrypted DNS option to the router\n  # advertisement, so hosts can discover DNS over TLS, HTTPS, or QUIC resolvers\n  # as described in RFC 9463.\n  [[interfaces.plugins]]\n  name = \"dnr\"\n  # The priority of this resolver relative to those in other \"dnr\" plugins.\n  # Lower values are preferred. Must be between 1 and 65535. Defaults to 1.\n  service_priority = 1\n  # The authentication domain name used to verify the resolver's certificate.\n  authentication_domain_name = \"dns.example.com\"\n  # One or more IPv6 addresses of the resolver. The option must not exceed\n  # 2040 bytes, which limits the number of servers to about 125.\n  servers = [\"2001:db8::53\"]\n  # The application protocols supported by the resolver, such as \"dot\", \"h2\",\n  # \"h3\", or \"doq\". At least one must be specified.\n  alpn = [\"dot\", \"h2\"]\n  # Optional: the port used to reach the resolver, if not the protocol default.\n  port = 853\n  # Optional: the URI template for DNS over HTTPS. Must contain \"{?dns}\".\n  doh_path = \"/dns-query{?dns}\"\n  # The maximum time this resolver may be used for name resolution. An empty\n  # string or 0 means this resolver should no longer be used. \"auto\" will\n  # compute a sane default. \"infinite\" means this resolver should be used\n  # forever.\n  lifetime = \"auto\"\n\n  # \"home_agent\" plugin: attaches a NDP Home Agent Information option to the\n  # router advertisement and sets the Mobile IPv6 home agent flag, as described\n  # in RFC 6275.\n  [[interfaces.plugins]]\n  name = \"home_agent\"\n  # The preference of this home agent relative to other home agents. Must be\n  # between -32768 and 32767. Higher values are preferred. Defaults to 0.\n  preference = 0\n  # The time this router may serve as a home agent. Must be between 1 and 65535\n  # seconds. \"auto\" uses the value of default_lifetime.\n  lifetime = \"auto\"\n\n  # \"mtu\" plugin: attaches a NDP MTU option to the router advertisement.\n  [[interfaces.plugins]]\n  name = \"mtu\"\n  mtu = 1500\n\n  # \"route\" plugin: attaches a NDP Route Information option to the router\n  # advertisement, indicating that a more-specific route is reachable through\n  # this router.\n  [[interfaces.plugins]]\n  name = \"route\"\n  # Serve an explicit IPv6 route. As with the \"prefix\" plugin, \"::/N\" serves\n  # a route for each IPv6 prefix on this interface configured with a /N CIDR\n  # mask, and \"::/0\" serves a default route.\n  prefix = \"fd00::/48\"\n  # The preference of this route relative to those served by other routers:\n  # \"low\", \"medium\", or \"high\". Defaults to \"medium\".\n  preference = \"medium\"\n  # The maximum time this route may be used. An empty string or 0 means this\n  # route should no longer be used. \"auto\" will compute a sane default.\n  # \"infinite\" means this route should be used forever.\n  lifetime = \"auto\"\n\n  # \"pref64\" plugin: attaches a NDP PREF64 option to the router advertisement,\n  # so hosts can synthesize IPv6 addresses for NAT64 without DNS64.\n  [[interfaces.plugins]]\n  name = \"pref64\"\n  # The NAT64 prefix. Must be a /32, /40, /48, /56, /64, or /96 prefix.\n  prefix = \"64:ff9b::/96\"\n  # The maximum time this NAT64 prefix may be used, rounded up to a multiple\n  # of 8 seconds. Must not exceed 65528 seconds. An empty string or 0 means\n  # this prefix should no longer be used. \"auto\" will compute a sane default\n  # of 3 * max_interval.\n  lifetime = \"auto\"\n\n  # \"captive_portal\" plugin: attaches a NDP Captive-Portal option to the\n  # router advertisement. Only one may be configured per interface.\n  [[interfaces.plugins]]\n  name = \"captive_portal\"\n  # The URI of the captive portal API. Must be an absolute https URL, or\n  # \"urn:ietf:params:capport:unrestricted\" to indicate that there is no\n  # captive portal on this network.\n  uri = \"https://portal.example.com/api\"\n\n  # \"raw\" plugin: attaches an arbitrary NDP option to the router advertisement,\n  # for options which are not otherwise supported by CoreRAD.\n  [[interfaces.plugins]]\n  name = \"raw\"\n  # The NDP option type. Must be between 0 and 255.\n  type = 253\n  # The option value, which must not include the type and length fields. The\n  # value length plus 2 bytes must be a multiple of 8 bytes.\n  value = \"000102030405\"\n  # The encoding of value: \"hex\" or \"base64\". Defaults to \"hex\".\n  encoding = \"hex\"\n\n# Enable or disable the debug HTTP server for facilities such as Prometheus\n# metrics and pprof support.\n#\n# Warning: do not expose pprof on an untrusted network!\n[debug]\naddress = \"localhost:9430\"\nprometheus = true\npprof = false\n"
 
 // A file is the raw top-level configuration file representation.
 type file struct {
@@ -36,17 +37,30 @@ type file struct {
 
 // A rawInterface is the raw configuration file representation of an Interface.
 type rawInterface struct {
-	Name               string                      `toml:"name"`
-	SendAdvertisements bool                        `toml:"send_advertisements"`
-	MaxInterval        string                      `toml:"max_interval"`
-	MinInterval        string                      `toml:"min_interval"`
-	Managed            bool                        `toml:"managed"`
-	OtherConfig        bool                        `toml:"other_config"`
-	ReachableTime      string                      `toml:"reachable_time"`
-	RetransmitTimer    string                      `toml:"retransmit_timer"`
-	HopLimit           int                         `toml:"hop_limit"`
-	DefaultLifetime    string                      `toml:"default_lifetime"`
-	Plugins            []map[string]toml.Primitive `toml:"plugins"`
+	Name                        string                      `toml:"name"`
+	WaitForInterface            bool                        `toml:"wait_for_interface"`
+	LinkLocalTimeout            string                      `toml:"link_local_timeout"`
+	SendAdvertisements          bool                        `toml:"send_advertisements"`
+	MaxInterval                 string                      `toml:"max_interval"`
+	MinInterval                 string                      `toml:"min_interval"`
+	AdvertisementIntervalOption bool                        `toml:"advertisement_interval_option"`
+	Managed                     bool                        `toml:"managed"`
+	OtherConfig                 bool                        `toml:"other_config"`
+	MobileIPv6HomeAgent         bool                        `toml:"mobile_ipv6_home_agent"`
+	NeighborDiscoveryProxy      bool                        `toml:"neighbor_discovery_proxy"`
+	Preference                  string                      `toml:"preference"`
+	ReachableTime               string                      `toml:"reachable_time"`
+	RetransmitTimer             string                      `toml:"retransmit_timer"`
+	HopLimit                    int                         `toml:"hop_limit"`
+	DefaultLifetime             string                      `toml:"default_lifetime"`
+	ShutdownDeprecatePrefixes   bool                        `toml:"shutdown_deprecate_prefixes"`
+	ShutdownValidLifetime       string                      `toml:"shutdown_valid_lifetime"`
+	ShutdownTimeout             string                      `toml:"shutdown_timeout"`
+	SolicitationInterval        string                      `toml:"solicitation_interval"`
+	SolicitationBurst           int                         `toml:"solicitation_burst"`
+	SolicitationGlobalInterval  string                      `toml:"solicitation_global_interval"`
+	Sysctl                      Sysctls                     `toml:"sysctl"`
+	Plugins                     []map[string]toml.Primitive `toml:"plugins"`
 }
 
 // Config specifies the configuration for CoreRAD.
@@ -57,14 +71,59 @@ type Config struct {
 
 // An Interface provides configuration for an individual interface.
 type Interface struct {
-	Name                           string
+	Name             string
+	WaitForInterface bool
+
+	// LinkLocalTimeout bounds the time spent waiting on startup for an IPv6
+	// link-local address which has completed duplicate address detection.
+	// Zero uses any link-local address immediately.
+	LinkLocalTimeout time.Duration
+
 	SendAdvertisements             bool
 	MinInterval, MaxInterval       time.Duration
+	AdvertisementIntervalOption    bool
 	Managed, OtherConfig           bool
+	MobileIPv6HomeAgent            bool
+	NeighborDiscoveryProxy         bool
+	Preference                     ndp.RouterSelectionPreference
 	ReachableTime, RetransmitTimer time.Duration
 	HopLimit                       uint8
 	DefaultLifetime                time.Duration
-	Plugins                        []Plugin
+
+	// ShutdownDeprecatePrefixes specifies that the final router
+	// advertisements sent on shutdown deprecate all prefixes and expire all
+	// routes, RDNSS servers, and DNSSL domains. If ShutdownValidLifetime is
+	// non-zero, prefix valid lifetimes are also reduced to that value.
+	ShutdownDeprecatePrefixes bool
+	ShutdownValidLifetime     time.Duration
+
+	// ShutdownTimeout bounds the time spent sending final router
+	// advertisements on shutdown. Zero sends a single final advertisement.
+	ShutdownTimeout time.Duration
+
+	// Rate limits for router advertisements sent in response to router
+	// solicitations. Each soliciting host may receive up to SolicitationBurst
+	// advertisements at once, and earns another every SolicitationInterval.
+	// All hosts may receive one advertisement every
+	// SolicitationGlobalInterval. A zero interval disables its limit.
+	SolicitationInterval       time.Duration
+	SolicitationBurst          int
+	SolicitationGlobalInterval time.Duration
+
+	// Sysctls are set on startup and restored on shutdown.
+	Sysctls Sysctls
+
+	Plugins []Plugin
+}
+
+// Sysctls specifies IPv6 sysctls for an interface which are set when CoreRAD
+// starts and restored to their previous values when it stops. Nil values are
+// not managed.
+type Sysctls struct {
+	Forwarding     *bool `toml:"forwarding"`
+	AcceptRA       *int  `toml:"accept_ra"`
+	AcceptRADefrtr *bool `toml:"accept_ra_defrtr"`
+	MTU            *int  `toml:"mtu"`
 }
 
 // Debug provides configuration for debugging and observability.
@@ -117,6 +176,7 @@ func Parse(r io.Reader) (*Config, error) {
 		}
 
 		iface.Plugins = make([]Plugin, 0, len(ifi.Plugins))
+		var portal bool
 		for j, p := range ifi.Plugins {
 			plug, err := parsePlugin(md, p)
 			if err != nil {
@@ -124,6 +184,14 @@ func Parse(r io.Reader) (*Config, error) {
 				return nil, fmt.Errorf("interface %d/%q, plugin %d: %v", i, ifi.Name, j, err)
 			}
 
+			// Only one captive portal URI may be advertised on a link.
+			if _, ok := plug.(*CaptivePortal); ok {
+				if portal {
+					return nil, fmt.Errorf("interface %d/%q, plugin %d: only one %q plugin may be configured", i, ifi.Name, j, plug.Name())
+				}
+				portal = true
+			}
+
 			iface.Plugins = append(iface.Plugins, plug)
 		}
 
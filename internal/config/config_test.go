@@ -22,6 +22,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/mdlayher/corerad/internal/config"
+	"github.com/mdlayher/ndp"
 )
 
 func TestParse(t *testing.T) {
@@ -76,6 +77,21 @@ func TestParse(t *testing.T) {
 			  name = "bad"
 			`,
 		},
+		{
+			name: "bad multiple captive portals",
+			s: `
+			[[interfaces]]
+			name = "eth0"
+
+			  [[interfaces.plugins]]
+			  name = "captive_portal"
+			  uri = "https://example.com/capport"
+
+			  [[interfaces.plugins]]
+			  name = "captive_portal"
+			  uri = "urn:ietf:params:capport:unrestricted"
+			`,
+		},
 		{
 			name: "bad debug address",
 			s: `
@@ -95,11 +111,16 @@ func TestParse(t *testing.T) {
 			`,
 			c: &config.Config{
 				Interfaces: []config.Interface{{
-					Name:               "eth0",
-					SendAdvertisements: true,
-					MinInterval:        3*time.Minute + 18*time.Second,
-					MaxInterval:        10 * time.Minute,
-					Plugins:            []config.Plugin{},
+					Name:                       "eth0",
+					SendAdvertisements:         true,
+					LinkLocalTimeout:           10 * time.Second,
+					MinInterval:                3*time.Minute + 18*time.Second,
+					MaxInterval:                10 * time.Minute,
+					ShutdownTimeout:            10 * time.Second,
+					SolicitationInterval:       1 * time.Second,
+					SolicitationBurst:          3,
+					SolicitationGlobalInterval: 10 * time.Millisecond,
+					Plugins:                    []config.Plugin{},
 				}},
 			},
 			ok: true,
@@ -134,15 +155,38 @@ func TestParse(t *testing.T) {
 			  lifetime = "auto"
 			  domain_names = ["lan.example.com"]
 
+			  [[interfaces.plugins]]
+			  name = "route"
+			  prefix = "fd00::/48"
+			  preference = "high"
+
 			[[interfaces]]
 			name = "eth1"
+			wait_for_interface = true
+			link_local_timeout = "30s"
 			min_interval = "auto"
 			max_interval = "4s"
+			advertisement_interval_option = true
 			default_lifetime = "8s"
 			managed = true
 			other_config = true
+			mobile_ipv6_home_agent = true
+			neighbor_discovery_proxy = true
+			preference = "low"
 			reachable_time = "30s"
 			retransmit_timer = "5s"
+			shutdown_deprecate_prefixes = true
+			shutdown_valid_lifetime = "2h"
+			shutdown_timeout = "5s"
+			solicitation_interval = "4s"
+			solicitation_burst = 5
+			solicitation_global_interval = "0s"
+
+			  [interfaces.sysctl]
+			  forwarding = true
+			  accept_ra = 0
+			  accept_ra_defrtr = false
+			  mtu = 1480
 
 			[debug]
 			address = "localhost:9430"
@@ -152,12 +196,17 @@ func TestParse(t *testing.T) {
 			c: &config.Config{
 				Interfaces: []config.Interface{
 					{
-						Name:               "eth0",
-						SendAdvertisements: true,
-						MinInterval:        6 * time.Minute,
-						MaxInterval:        10 * time.Minute,
-						HopLimit:           64,
-						DefaultLifetime:    30 * time.Minute,
+						Name:                       "eth0",
+						SendAdvertisements:         true,
+						LinkLocalTimeout:           10 * time.Second,
+						MinInterval:                6 * time.Minute,
+						MaxInterval:                10 * time.Minute,
+						HopLimit:                   64,
+						DefaultLifetime:            30 * time.Minute,
+						ShutdownTimeout:            10 * time.Second,
+						SolicitationInterval:       1 * time.Second,
+						SolicitationBurst:          3,
+						SolicitationGlobalInterval: 10 * time.Millisecond,
 						Plugins: []config.Plugin{
 							&config.Prefix{
 								Prefix:            mustCIDR("::/64"),
@@ -165,6 +214,7 @@ func TestParse(t *testing.T) {
 								Autonomous:        defaultPrefix.Autonomous,
 								ValidLifetime:     defaultPrefix.ValidLifetime,
 								PreferredLifetime: defaultPrefix.PreferredLifetime,
+								DeprecationPeriod: 2 * time.Hour,
 							},
 							&config.Prefix{
 								Prefix:            mustCIDR("2001:db8::/64"),
@@ -172,6 +222,7 @@ func TestParse(t *testing.T) {
 								Autonomous:        false,
 								ValidLifetime:     defaultPrefix.ValidLifetime,
 								PreferredLifetime: defaultPrefix.PreferredLifetime,
+								DeprecationPeriod: 2 * time.Hour,
 							},
 							&config.RDNSS{
 								Lifetime: config.DurationAuto,
@@ -181,19 +232,41 @@ func TestParse(t *testing.T) {
 								Lifetime:    config.DurationAuto,
 								DomainNames: []string{"lan.example.com"},
 							},
+							&config.Route{
+								Prefix:     mustCIDR("fd00::/48"),
+								Preference: ndp.High,
+								Lifetime:   config.DurationAuto,
+							},
 						},
 					},
 					{
-						Name:               "eth1",
-						SendAdvertisements: false,
-						MinInterval:        4 * time.Second,
-						MaxInterval:        4 * time.Second,
-						Managed:            true,
-						OtherConfig:        true,
-						ReachableTime:      30 * time.Second,
-						RetransmitTimer:    5 * time.Second,
-						DefaultLifetime:    8 * time.Second,
-						Plugins:            []config.Plugin{},
+						Name:                        "eth1",
+						WaitForInterface:            true,
+						LinkLocalTimeout:            30 * time.Second,
+						SendAdvertisements:          false,
+						MinInterval:                 4 * time.Second,
+						MaxInterval:                 4 * time.Second,
+						AdvertisementIntervalOption: true,
+						Managed:                     true,
+						OtherConfig:                 true,
+						MobileIPv6HomeAgent:         true,
+						NeighborDiscoveryProxy:      true,
+						Preference:                  ndp.Low,
+						ReachableTime:               30 * time.Second,
+						RetransmitTimer:             5 * time.Second,
+						DefaultLifetime:             8 * time.Second,
+						ShutdownDeprecatePrefixes:   true,
+						ShutdownValidLifetime:       2 * time.Hour,
+						ShutdownTimeout:             5 * time.Second,
+						SolicitationInterval:        4 * time.Second,
+						SolicitationBurst:           5,
+						Sysctls: config.Sysctls{
+							Forwarding:     boolp(true),
+							AcceptRA:       intp(0),
+							AcceptRADefrtr: boolp(false),
+							MTU:            intp(1480),
+						},
+						Plugins: []config.Plugin{},
 					},
 				},
 				Debug: config.Debug{
@@ -248,3 +321,6 @@ func mustCIDR(s string) *net.IPNet {
 func panicf(format string, a ...interface{}) {
 	panic(fmt.Sprintf(format, a...))
 }
+
+func boolp(b bool) *bool { return &b }
+func intp(i int) *int    { return &i }
@@ -77,17 +77,73 @@ func parseInterface(ifi rawInterface) (*Interface, error) {
 		return nil, err
 	}
 
+	var shutdownValid time.Duration
+	if ifi.ShutdownValidLifetime != "" {
+		if !ifi.ShutdownDeprecatePrefixes {
+			return nil, fmt.Errorf("shutdown valid lifetime may only be set when shutdown_deprecate_prefixes is set")
+		}
+
+		d, err := time.ParseDuration(ifi.ShutdownValidLifetime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shutdown valid lifetime: %v", err)
+		}
+		shutdownValid = d
+	}
+
+	if shutdownValid < 0 {
+		return nil, fmt.Errorf("shutdown valid lifetime (%d) must not be negative", int(shutdownValid.Seconds()))
+	}
+
+	shutdownTimeout, err := parseShutdownTimeout(ifi.ShutdownTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	linkLocalTimeout, err := parseLinkLocalTimeout(ifi.LinkLocalTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	solicitInterval, solicitBurst, solicitGlobal, err := parseSolicitationLimits(
+		ifi.SolicitationInterval, ifi.SolicitationBurst, ifi.SolicitationGlobalInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateSysctls(ifi.Sysctl); err != nil {
+		return nil, err
+	}
+
+	prf := value{v: ifi.Preference}
+	preference := prf.Preference()
+	if err := prf.Err(); err != nil {
+		return nil, fmt.Errorf("invalid preference: %v", err)
+	}
+
 	return &Interface{
-		Name:               ifi.Name,
-		SendAdvertisements: ifi.SendAdvertisements,
-		MinInterval:        minInterval,
-		MaxInterval:        maxInterval,
-		Managed:            ifi.Managed,
-		OtherConfig:        ifi.OtherConfig,
-		ReachableTime:      reachable,
-		RetransmitTimer:    retrans,
-		HopLimit:           uint8(ifi.HopLimit),
-		DefaultLifetime:    lifetime,
+		Name:                        ifi.Name,
+		WaitForInterface:            ifi.WaitForInterface,
+		LinkLocalTimeout:            linkLocalTimeout,
+		SendAdvertisements:          ifi.SendAdvertisements,
+		MinInterval:                 minInterval,
+		MaxInterval:                 maxInterval,
+		AdvertisementIntervalOption: ifi.AdvertisementIntervalOption,
+		Managed:                     ifi.Managed,
+		OtherConfig:                 ifi.OtherConfig,
+		MobileIPv6HomeAgent:         ifi.MobileIPv6HomeAgent,
+		NeighborDiscoveryProxy:      ifi.NeighborDiscoveryProxy,
+		Preference:                  preference,
+		ReachableTime:               reachable,
+		RetransmitTimer:             retrans,
+		HopLimit:                    uint8(ifi.HopLimit),
+		DefaultLifetime:             lifetime,
+		ShutdownDeprecatePrefixes:   ifi.ShutdownDeprecatePrefixes,
+		ShutdownValidLifetime:       shutdownValid,
+		ShutdownTimeout:             shutdownTimeout,
+		SolicitationInterval:        solicitInterval,
+		SolicitationBurst:           solicitBurst,
+		SolicitationGlobalInterval:  solicitGlobal,
+		Sysctls:                     ifi.Sysctl,
 	}, nil
 }
 
@@ -143,3 +199,103 @@ func parseDefaultLifetime(s string, max time.Duration) (time.Duration, error) {
 
 	return lt, nil
 }
+
+// parseShutdownTimeout parses a shutdown_timeout string.
+func parseShutdownTimeout(s string) (time.Duration, error) {
+	if s == "" || s == "auto" {
+		// Allow enough time to send the maximum number of final router
+		// advertisements, per:
+		// https://tools.ietf.org/html/rfc4861#section-10.
+		return 10 * time.Second, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid shutdown timeout: %v", err)
+	}
+
+	if d < 0 {
+		return 0, fmt.Errorf("shutdown timeout (%d) must not be negative", int(d.Seconds()))
+	}
+
+	return d, nil
+}
+
+// parseLinkLocalTimeout parses a link_local_timeout string.
+func parseLinkLocalTimeout(s string) (time.Duration, error) {
+	if s == "" || s == "auto" {
+		// Allow time for duplicate address detection with several
+		// transmissions, per:
+		// https://tools.ietf.org/html/rfc4862#section-5.4.
+		return 10 * time.Second, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid link-local timeout: %v", err)
+	}
+
+	if d < 0 {
+		return 0, fmt.Errorf("link-local timeout (%d) must not be negative", int(d.Seconds()))
+	}
+
+	return d, nil
+}
+
+// validateSysctls verifies that managed sysctl values are valid.
+func validateSysctls(s Sysctls) error {
+	if s.AcceptRA != nil && (*s.AcceptRA < 0 || *s.AcceptRA > 2) {
+		return fmt.Errorf("sysctl accept_ra (%d) must be between 0 and 2", *s.AcceptRA)
+	}
+
+	// The IPv6 minimum link MTU, per:
+	// https://tools.ietf.org/html/rfc8200#section-5.
+	if s.MTU != nil && (*s.MTU < 1280 || *s.MTU > 65535) {
+		return fmt.Errorf("sysctl mtu (%d) must be between 1280 and 65535", *s.MTU)
+	}
+
+	return nil
+}
+
+// parseSolicitationLimits parses the router solicitation rate limit
+// parameters and computes their default values.
+func parseSolicitationLimits(interval string, burst int, global string) (time.Duration, int, time.Duration, error) {
+	// By default, allow a host to send the maximum number of solicitations
+	// permitted by the RFC at once, with some leeway for retransmissions:
+	// https://tools.ietf.org/html/rfc4861#section-10.
+	i := 1 * time.Second
+	if interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid solicitation interval: %v", err)
+		}
+		i = d
+	}
+
+	if i < 0 {
+		return 0, 0, 0, fmt.Errorf("solicitation interval (%s) must not be negative", i)
+	}
+
+	if burst == 0 {
+		burst = 3
+	}
+
+	if burst < 1 || burst > 1000 {
+		return 0, 0, 0, fmt.Errorf("solicitation burst (%d) must be between 1 and 1000", burst)
+	}
+
+	g := 10 * time.Millisecond
+	if global != "" {
+		d, err := time.ParseDuration(global)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid solicitation global interval: %v", err)
+		}
+		g = d
+	}
+
+	if g < 0 {
+		return 0, 0, 0, fmt.Errorf("solicitation global interval (%s) must not be negative", g)
+	}
+
+	return i, burst, g, nil
+}
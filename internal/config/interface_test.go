@@ -118,12 +118,110 @@ func Test_parseInterfaceErrors(t *testing.T) {
 				DefaultLifetime: "2s",
 			},
 		},
+		{
+			name: "preference",
+			ifi: rawInterface{
+				Preference: "foo",
+			},
+		},
 		{
 			name: "default lifetime too high",
 			ifi: rawInterface{
 				DefaultLifetime: "9001s",
 			},
 		},
+		{
+			name: "shutdown valid lifetime without deprecate prefixes",
+			ifi: rawInterface{
+				ShutdownValidLifetime: "2h",
+			},
+		},
+		{
+			name: "shutdown valid lifetime duration",
+			ifi: rawInterface{
+				ShutdownDeprecatePrefixes: true,
+				ShutdownValidLifetime:     "foo",
+			},
+		},
+		{
+			name: "shutdown valid lifetime negative",
+			ifi: rawInterface{
+				ShutdownDeprecatePrefixes: true,
+				ShutdownValidLifetime:     "-1s",
+			},
+		},
+		{
+			name: "shutdown timeout duration",
+			ifi: rawInterface{
+				ShutdownTimeout: "foo",
+			},
+		},
+		{
+			name: "shutdown timeout negative",
+			ifi: rawInterface{
+				ShutdownTimeout: "-1s",
+			},
+		},
+		{
+			name: "link-local timeout duration",
+			ifi: rawInterface{
+				LinkLocalTimeout: "foo",
+			},
+		},
+		{
+			name: "link-local timeout negative",
+			ifi: rawInterface{
+				LinkLocalTimeout: "-1s",
+			},
+		},
+		{
+			name: "sysctl accept_ra",
+			ifi: rawInterface{
+				Sysctl: Sysctls{AcceptRA: func() *int { v := 3; return &v }()},
+			},
+		},
+		{
+			name: "sysctl mtu",
+			ifi: rawInterface{
+				Sysctl: Sysctls{MTU: func() *int { v := 1000; return &v }()},
+			},
+		},
+		{
+			name: "solicitation interval duration",
+			ifi: rawInterface{
+				SolicitationInterval: "foo",
+			},
+		},
+		{
+			name: "solicitation interval negative",
+			ifi: rawInterface{
+				SolicitationInterval: "-1s",
+			},
+		},
+		{
+			name: "solicitation burst too low",
+			ifi: rawInterface{
+				SolicitationBurst: -1,
+			},
+		},
+		{
+			name: "solicitation burst too high",
+			ifi: rawInterface{
+				SolicitationBurst: 1001,
+			},
+		},
+		{
+			name: "solicitation global interval duration",
+			ifi: rawInterface{
+				SolicitationGlobalInterval: "foo",
+			},
+		},
+		{
+			name: "solicitation global interval negative",
+			ifi: rawInterface{
+				SolicitationGlobalInterval: "-1s",
+			},
+		},
 	}
 
 	for _, tt := range tests {
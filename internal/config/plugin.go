@@ -14,13 +14,18 @@
 package config
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
 	"net"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/mdlayher/ndp"
 )
 
 // A Plugin specifies a CoreRAD plugin's configuration.
@@ -53,14 +58,26 @@ func parsePlugin(md toml.MetaData, m map[string]toml.Primitive) (Plugin, error)
 	// required and decode its individual configuration.
 	var p Plugin
 	switch name {
+	case "captive_portal":
+		p = new(CaptivePortal)
+	case "dnr":
+		p = NewDNR()
 	case "dnssl":
 		p = new(DNSSL)
+	case "home_agent":
+		p = NewHomeAgent()
 	case "mtu":
 		p = new(MTU)
+	case "pref64":
+		p = NewPREF64()
 	case "prefix":
 		p = NewPrefix()
+	case "raw":
+		p = new(Raw)
 	case "rdnss":
 		p = new(RDNSS)
+	case "route":
+		p = NewRoute()
 	default:
 		return nil, fmt.Errorf("unknown plugin %q", name)
 	}
@@ -72,10 +89,179 @@ func parsePlugin(md toml.MetaData, m map[string]toml.Primitive) (Plugin, error)
 	return p, nil
 }
 
+// A DNR configures a NDP Encrypted DNS option, as described in RFC 9463.
+type DNR struct {
+	ServicePriority          uint16
+	AuthenticationDomainName string
+	Servers                  []net.IP
+	ALPN                     []string
+	Port                     uint16
+	DoHPath                  string
+	Lifetime                 time.Duration
+}
+
+// NewDNR creates a DNR with default values.
+func NewDNR() *DNR {
+	return &DNR{
+		ServicePriority: 1,
+		Lifetime:        DurationAuto,
+	}
+}
+
+// Name implements Plugin.
+func (d *DNR) Name() string { return "dnr" }
+
+// String implements Plugin.
+func (d *DNR) String() string {
+	ips := make([]string, 0, len(d.Servers))
+	for _, s := range d.Servers {
+		ips = append(ips, s.String())
+	}
+
+	var params []string
+	params = append(params, fmt.Sprintf("alpn: [%s]", strings.Join(d.ALPN, ", ")))
+	if d.Port != 0 {
+		params = append(params, fmt.Sprintf("port: %d", d.Port))
+	}
+	if d.DoHPath != "" {
+		params = append(params, fmt.Sprintf("dohpath: %q", d.DoHPath))
+	}
+
+	return fmt.Sprintf("%s, priority: %d, servers: [%s], %s, lifetime: %s",
+		d.AuthenticationDomainName, d.ServicePriority, strings.Join(ips, ", "),
+		strings.Join(params, ", "), d.Lifetime)
+}
+
+// Decode implements Plugin.
+func (d *DNR) Decode(md toml.MetaData, m map[string]toml.Primitive) error {
+	for k := range m {
+		var v value
+		if err := md.PrimitiveDecode(m[k], &v.v); err != nil {
+			return err
+		}
+
+		switch k {
+		case "name":
+			// Already handled.
+		case "alpn":
+			d.ALPN = v.StringSlice()
+		case "authentication_domain_name":
+			d.AuthenticationDomainName = v.string()
+		case "doh_path":
+			d.DoHPath = v.string()
+		case "lifetime":
+			d.Lifetime = v.Duration()
+		case "port":
+			d.Port = uint16(v.Int(1, 65535))
+		case "servers":
+			d.Servers = v.IPSlice()
+		case "service_priority":
+			// Priority 0 is reserved for ADN-only mode, which is not
+			// supported in router advertisements.
+			d.ServicePriority = uint16(v.Int(1, 65535))
+		default:
+			return fmt.Errorf("invalid key %q", k)
+		}
+
+		if err := v.Err(); err != nil {
+			return fmt.Errorf("parsing key %q: %v", k, err)
+		}
+	}
+
+	return d.validate()
+}
+
+// validate verifies that a DNR is valid.
+func (d *DNR) validate() error {
+	if err := checkDomainName(d.AuthenticationDomainName); err != nil {
+		return fmt.Errorf("invalid authentication domain name: %v", err)
+	}
+
+	if len(d.Servers) == 0 {
+		return errors.New("servers must not be empty")
+	}
+
+	// The "alpn" SvcParam is mandatory, per RFC 9463, section 3.1.5.
+	if len(d.ALPN) == 0 {
+		return errors.New("ALPN must not be empty")
+	}
+	for _, a := range d.ALPN {
+		if l := len(a); l == 0 || l > 255 {
+			return fmt.Errorf("ALPN protocol ID %q must be between 1 and 255 bytes", a)
+		}
+	}
+
+	// See: https://tools.ietf.org/html/rfc9461#section-5.
+	if d.DoHPath != "" && (!strings.HasPrefix(d.DoHPath, "/") || !strings.Contains(d.DoHPath, "{?dns}")) {
+		return fmt.Errorf("DoH path %q must be a relative URI template containing \"{?dns}\"", d.DoHPath)
+	}
+
+	// Each SvcParam value length is a 16-bit field.
+	if l := len(d.DoHPath); l > math.MaxUint16 {
+		return fmt.Errorf("DoH path length of %d bytes is too long", l)
+	}
+
+	// Each SvcParam consists of a 2 byte key, a 2 byte length, and its value.
+	params := 4
+	for _, a := range d.ALPN {
+		params += 1 + len(a)
+	}
+	if d.Port != 0 {
+		params += 4 + 2
+	}
+	if d.DoHPath != "" {
+		params += 4 + len(d.DoHPath)
+	}
+
+	// The option length is specified in units of 8 octets and includes the
+	// type and length fields, service priority, lifetime, and the
+	// length-prefixed authentication domain name, addresses, and SvcParams.
+	adn := len(strings.TrimSuffix(d.AuthenticationDomainName, ".")) + 2
+	if l := 2 + 2 + 4 + 2 + adn + 2 + 16*len(d.Servers) + 2 + params; l > 255*8 {
+		return fmt.Errorf("encoded option length of %d bytes exceeds the maximum of %d bytes", l, 255*8)
+	}
+
+	return nil
+}
+
+// checkDomainName verifies that s is a fully qualified domain name which can
+// be encoded in DNS wire format.
+func checkDomainName(s string) error {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" {
+		return errors.New("domain name must not be empty")
+	}
+
+	// Each label is prefixed by its length, and the name is terminated by the
+	// zero length root label.
+	n := 1
+	for _, l := range strings.Split(s, ".") {
+		if len(l) == 0 || len(l) > 63 {
+			return fmt.Errorf("label %q in domain name %q must be between 1 and 63 bytes", l, s)
+		}
+
+		n += 1 + len(l)
+	}
+
+	if n > 255 {
+		return fmt.Errorf("domain name %q exceeds 255 bytes", s)
+	}
+
+	return nil
+}
+
 // DNSSL configures a NDP DNS Search List option.
 type DNSSL struct {
 	Lifetime    time.Duration
 	DomainNames []string
+
+	// DecrementLifetimes specifies that Lifetime counts down in real time,
+	// rather than being reset in each advertisement.
+	DecrementLifetimes bool
+
+	// If set, DomainNames are populated from the search domains in the
+	// resolv.conf file at this path.
+	ResolvConf string
 }
 
 // Name implements Plugin.
@@ -83,12 +269,17 @@ func (d *DNSSL) Name() string { return "DNSSL" }
 
 // String implements Plugin.
 func (d *DNSSL) String() string {
-	return fmt.Sprintf("domain names: [%s], lifetime: %s",
-		strings.Join(d.DomainNames, ", "), d.Lifetime)
+	names := "[" + strings.Join(d.DomainNames, ", ") + "]"
+	if d.ResolvConf != "" {
+		names = fmt.Sprintf("auto (%s)", d.ResolvConf)
+	}
+
+	return fmt.Sprintf("domain names: %s, lifetime: %s", names, d.Lifetime)
 }
 
 // Decode implements Plugin.
 func (d *DNSSL) Decode(md toml.MetaData, m map[string]toml.Primitive) error {
+	var auto bool
 	for k := range m {
 		var v value
 		if err := md.PrimitiveDecode(m[k], &v.v); err != nil {
@@ -98,10 +289,18 @@ func (d *DNSSL) Decode(md toml.MetaData, m map[string]toml.Primitive) error {
 		switch k {
 		case "name":
 			// Already handled.
+		case "decrement_lifetimes":
+			d.DecrementLifetimes = v.Bool()
 		case "lifetime":
 			d.Lifetime = v.Duration()
 		case "domain_names":
-			d.DomainNames = v.StringSlice()
+			if v.Auto() {
+				auto = true
+			} else {
+				d.DomainNames = v.StringSlice()
+			}
+		case "resolv_conf":
+			d.ResolvConf = v.string()
 		default:
 			return fmt.Errorf("invalid key %q", k)
 		}
@@ -111,7 +310,11 @@ func (d *DNSSL) Decode(md toml.MetaData, m map[string]toml.Primitive) error {
 		}
 	}
 
-	return nil
+	if err := checkDecrement(d.DecrementLifetimes, d.Lifetime); err != nil {
+		return err
+	}
+
+	return checkResolvConf(auto, &d.ResolvConf)
 }
 
 // A Prefix configures a NDP Prefix Information option.
@@ -119,10 +322,38 @@ type Prefix struct {
 	Prefix            *net.IPNet
 	OnLink            bool
 	Autonomous        bool
+	RouterAddress     bool
 	ValidLifetime     time.Duration
 	PreferredLifetime time.Duration
+
+	// DecrementLifetimes specifies that ValidLifetime and PreferredLifetime
+	// count down in real time, rather than being reset in each advertisement.
+	DecrementLifetimes bool
+
+	// If set, the prefix is derived from a prefix delegated to this router,
+	// which is read from the JSON file at DelegatedPrefixFile. The ::/N length
+	// of Prefix selects the length of subnet number Subnet of the delegated
+	// prefix.
+	DelegatedPrefixFile string
+	Subnet              int
+
+	// DeprecationPeriod specifies how long a prefix expanded from ::/N
+	// continues to be advertised as deprecated after it disappears from the
+	// interface. Zero disables deprecation.
+	DeprecationPeriod time.Duration
+
+	// Filters which select the prefixes served by this Prefix. A prefix must
+	// be within Scope, within one of Include if Include is not empty, and not
+	// within any of Exclude.
+	Scope   Scope
+	Include []*net.IPNet
+	Exclude []*net.IPNet
 }
 
+// maxDeprecationPeriod is the default deprecation period for prefixes, as
+// recommended by RFC 8978, section 3.
+const maxDeprecationPeriod = 2 * time.Hour
+
 // NewPrefix creates a Prefix with default values configured as specified in
 // RFC 4861, section 6.2.1.
 func NewPrefix() *Prefix {
@@ -131,6 +362,7 @@ func NewPrefix() *Prefix {
 		Autonomous:        true,
 		ValidLifetime:     30 * 24 * time.Hour, // 30 days
 		PreferredLifetime: 7 * 24 * time.Hour,  // 7 days
+		DeprecationPeriod: DurationAuto,
 	}
 }
 
@@ -146,9 +378,29 @@ func (p *Prefix) String() string {
 	if p.Autonomous {
 		flags = append(flags, "autonomous")
 	}
+	if p.RouterAddress {
+		flags = append(flags, "router-address")
+	}
+	if p.DecrementLifetimes {
+		flags = append(flags, "decrement-lifetimes")
+	}
+
+	prefix := p.Prefix.String()
+	if p.DelegatedPrefixFile != "" {
+		prefix = fmt.Sprintf("%s (subnet %d of %s)", prefix, p.Subnet, p.DelegatedPrefixFile)
+	}
+	if p.Scope != ScopeAny {
+		prefix = fmt.Sprintf("%s (scope: %s)", prefix, p.Scope)
+	}
+	if len(p.Include) > 0 {
+		prefix = fmt.Sprintf("%s (include: %s)", prefix, ipNets(p.Include))
+	}
+	if len(p.Exclude) > 0 {
+		prefix = fmt.Sprintf("%s (exclude: %s)", prefix, ipNets(p.Exclude))
+	}
 
 	return fmt.Sprintf("%s [%s], preferred: %s, valid: %s",
-		p.Prefix,
+		prefix,
 		strings.Join(flags, ","),
 		p.PreferredLifetime,
 		p.ValidLifetime,
@@ -157,6 +409,7 @@ func (p *Prefix) String() string {
 
 // Decode implements Plugin.
 func (p *Prefix) Decode(md toml.MetaData, m map[string]toml.Primitive) error {
+	var subnet bool
 	for k := range m {
 		var v value
 		if err := md.PrimitiveDecode(m[k], &v.v); err != nil {
@@ -168,12 +421,29 @@ func (p *Prefix) Decode(md toml.MetaData, m map[string]toml.Primitive) error {
 			// Already handled.
 		case "autonomous":
 			p.Autonomous = v.Bool()
+		case "decrement_lifetimes":
+			p.DecrementLifetimes = v.Bool()
+		case "delegated_prefix_file":
+			p.DelegatedPrefixFile = v.string()
+		case "deprecation_period":
+			p.DeprecationPeriod = v.Duration()
+		case "exclude":
+			p.Exclude = v.IPNetSlice()
+		case "include":
+			p.Include = v.IPNetSlice()
 		case "on_link":
 			p.OnLink = v.Bool()
 		case "preferred_lifetime":
 			p.PreferredLifetime = v.Duration()
 		case "prefix":
 			p.Prefix = v.IPNet()
+		case "router_address":
+			p.RouterAddress = v.Bool()
+		case "scope":
+			p.Scope = v.Scope()
+		case "subnet":
+			p.Subnet = v.Int(0, math.MaxInt32)
+			subnet = true
 		case "valid_lifetime":
 			p.ValidLifetime = v.Duration()
 		default:
@@ -185,6 +455,10 @@ func (p *Prefix) Decode(md toml.MetaData, m map[string]toml.Primitive) error {
 		}
 	}
 
+	if subnet && p.DelegatedPrefixFile == "" {
+		return errors.New("subnet may only be set when delegated_prefix_file is set")
+	}
+
 	return p.validate()
 }
 
@@ -194,6 +468,30 @@ func (p *Prefix) validate() error {
 		return errors.New("prefix must not be empty")
 	}
 
+	// A delegated prefix replaces the prefix bits, so only the length may be
+	// specified.
+	if p.DelegatedPrefixFile != "" {
+		if length, _ := p.Prefix.Mask.Size(); length == 0 || !p.Prefix.IP.Equal(net.IPv6zero) {
+			return fmt.Errorf("prefix %s must be of the form ::/N when delegated_prefix_file is set", p.Prefix)
+		}
+	}
+
+	// Link-local prefixes must never be advertised:
+	// https://tools.ietf.org/html/rfc4861#section-4.6.2.
+	if p.Scope == ScopeLinkLocal {
+		return errors.New("scope must not be link-local")
+	}
+
+	// Filters which reject every prefix leave nothing to advertise.
+	for _, ipn := range p.Exclude {
+		if length, _ := ipn.Mask.Size(); length == 0 {
+			return fmt.Errorf("exclude %s excludes all prefixes", ipn)
+		}
+	}
+	if p.DelegatedPrefixFile == "" && !IsWildcard(p.Prefix) && !p.Match(p.Prefix) {
+		return fmt.Errorf("prefix %s is excluded by scope, include, or exclude", p.Prefix)
+	}
+
 	// Use defaults for auto values.
 	def := NewPrefix()
 	switch p.ValidLifetime {
@@ -215,6 +513,127 @@ func (p *Prefix) validate() error {
 		return fmt.Errorf("preferred lifetime of %s exceeds valid lifetime of %s", p.PreferredLifetime, p.ValidLifetime)
 	}
 
+	switch {
+	case p.DeprecationPeriod == DurationAuto:
+		p.DeprecationPeriod = maxDeprecationPeriod
+		if p.ValidLifetime < p.DeprecationPeriod {
+			p.DeprecationPeriod = p.ValidLifetime
+		}
+	case p.DeprecationPeriod > p.ValidLifetime:
+		return fmt.Errorf("deprecation period of %s exceeds valid lifetime of %s", p.DeprecationPeriod, p.ValidLifetime)
+	}
+
+	return nil
+}
+
+// Match reports whether prefix is selected by the Scope, Include, and Exclude
+// filters of p.
+func (p *Prefix) Match(prefix *net.IPNet) bool {
+	// Link-local prefixes are never advertised, so ScopeAny only applies to
+	// global unicast prefixes.
+	if !prefix.IP.IsGlobalUnicast() || !p.Scope.Match(prefix.IP) {
+		return false
+	}
+
+	if len(p.Include) > 0 && !anyContains(p.Include, prefix) {
+		return false
+	}
+
+	return !anyContains(p.Exclude, prefix)
+}
+
+// anyContains reports whether any of ipns contains all of prefix.
+func anyContains(ipns []*net.IPNet, prefix *net.IPNet) bool {
+	length, _ := prefix.Mask.Size()
+	for _, ipn := range ipns {
+		if l, _ := ipn.Mask.Size(); l <= length && ipn.Contains(prefix.IP) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsWildcard reports whether ipn is a ::/N prefix which is expanded to the
+// prefixes on an interface.
+func IsWildcard(ipn *net.IPNet) bool {
+	// ::/0 is the default route rather than a wildcard.
+	length, _ := ipn.Mask.Size()
+	return length != 0 && ipn.IP.Equal(net.IPv6zero)
+}
+
+// ipNets formats ipns as a comma-separated list.
+func ipNets(ipns []*net.IPNet) string {
+	ss := make([]string, 0, len(ipns))
+	for _, ipn := range ipns {
+		ss = append(ss, ipn.String())
+	}
+
+	return strings.Join(ss, ", ")
+}
+
+// A HomeAgent configures a NDP Home Agent Information option, as described
+// in RFC 6275.
+type HomeAgent struct {
+	Preference int16
+	Lifetime   time.Duration
+}
+
+// NewHomeAgent creates a HomeAgent with default values.
+func NewHomeAgent() *HomeAgent {
+	return &HomeAgent{Lifetime: DurationAuto}
+}
+
+// Name implements Plugin.
+func (h *HomeAgent) Name() string { return "home_agent" }
+
+// String implements Plugin.
+func (h *HomeAgent) String() string {
+	return fmt.Sprintf("preference: %d, lifetime: %s", h.Preference, h.Lifetime)
+}
+
+// Decode implements Plugin.
+func (h *HomeAgent) Decode(md toml.MetaData, m map[string]toml.Primitive) error {
+	for k := range m {
+		var v value
+		if err := md.PrimitiveDecode(m[k], &v.v); err != nil {
+			return err
+		}
+
+		switch k {
+		case "name":
+			// Already handled.
+		case "lifetime":
+			h.Lifetime = v.Duration()
+		case "preference":
+			h.Preference = int16(v.Int(math.MinInt16, math.MaxInt16))
+		default:
+			return fmt.Errorf("invalid key %q", k)
+		}
+
+		if err := v.Err(); err != nil {
+			return fmt.Errorf("parsing key %q: %v", k, err)
+		}
+	}
+
+	return h.validate()
+}
+
+// maxHomeAgentLifetime is the maximum lifetime which can be represented by the
+// 16-bit lifetime field of a Home Agent Information option.
+const maxHomeAgentLifetime = math.MaxUint16 * time.Second
+
+// validate verifies that a HomeAgent is valid.
+func (h *HomeAgent) validate() error {
+	if h.Lifetime == DurationAuto {
+		return nil
+	}
+
+	// See: https://tools.ietf.org/html/rfc6275#section-7.4.
+	if h.Lifetime < time.Second || h.Lifetime > maxHomeAgentLifetime {
+		return fmt.Errorf("lifetime of %s must be between 1s and %s", h.Lifetime, maxHomeAgentLifetime)
+	}
+
 	return nil
 }
 
@@ -256,7 +675,19 @@ func (m *MTU) Decode(md toml.MetaData, mp map[string]toml.Primitive) error {
 // RDNSS configures a NDP Recursive DNS Servers option.
 type RDNSS struct {
 	Lifetime time.Duration
-	Servers  []net.IP
+
+	// DecrementLifetimes specifies that Lifetime counts down in real time,
+	// rather than being reset in each advertisement.
+	DecrementLifetimes bool
+
+	// Servers may contain the unspecified address "::", which is replaced by
+	// the interface's own addresses within Scope.
+	Servers []net.IP
+	Scope   Scope
+
+	// If set, Servers are populated from the IPv6 nameservers in the
+	// resolv.conf file at this path.
+	ResolvConf string
 }
 
 // Name implements Plugin.
@@ -269,11 +700,20 @@ func (r *RDNSS) String() string {
 		ips = append(ips, s.String())
 	}
 
-	return fmt.Sprintf("servers: [%s], lifetime: %s", strings.Join(ips, ", "), r.Lifetime)
+	servers := "[" + strings.Join(ips, ", ") + "]"
+	if r.Scope != ScopeAny {
+		servers += fmt.Sprintf(" (scope: %s)", r.Scope)
+	}
+	if r.ResolvConf != "" {
+		servers = fmt.Sprintf("auto (%s)", r.ResolvConf)
+	}
+
+	return fmt.Sprintf("servers: %s, lifetime: %s", servers, r.Lifetime)
 }
 
 // Decode implements Plugin.
 func (r *RDNSS) Decode(md toml.MetaData, m map[string]toml.Primitive) error {
+	var auto bool
 	for k := range m {
 		var v value
 		if err := md.PrimitiveDecode(m[k], &v.v); err != nil {
@@ -283,10 +723,20 @@ func (r *RDNSS) Decode(md toml.MetaData, m map[string]toml.Primitive) error {
 		switch k {
 		case "name":
 			// Already handled.
+		case "decrement_lifetimes":
+			r.DecrementLifetimes = v.Bool()
 		case "lifetime":
 			r.Lifetime = v.Duration()
+		case "resolv_conf":
+			r.ResolvConf = v.string()
+		case "scope":
+			r.Scope = v.Scope()
 		case "servers":
-			r.Servers = v.IPSlice()
+			if v.Auto() {
+				auto = true
+			} else {
+				r.Servers = v.IPSlice()
+			}
 		default:
 			return fmt.Errorf("invalid key %q", k)
 		}
@@ -296,5 +746,329 @@ func (r *RDNSS) Decode(md toml.MetaData, m map[string]toml.Primitive) error {
 		}
 	}
 
+	if r.Scope != ScopeAny && !r.wildcard() {
+		return errors.New(`scope may only be set when servers contains "::"`)
+	}
+
+	if err := checkDecrement(r.DecrementLifetimes, r.Lifetime); err != nil {
+		return err
+	}
+
+	return checkResolvConf(auto, &r.ResolvConf)
+}
+
+// wildcard reports whether r.Servers contains the unspecified address.
+func (r *RDNSS) wildcard() bool {
+	for _, s := range r.Servers {
+		if s.Equal(net.IPv6unspecified) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkDecrement verifies that a lifetime which decrements in real time has
+// an explicit value to count down from.
+func checkDecrement(decrement bool, lifetime time.Duration) error {
+	if decrement && lifetime == DurationAuto {
+		return errors.New(`decrement_lifetimes requires an explicit lifetime rather than "auto"`)
+	}
+
+	return nil
+}
+
+// defaultResolvConf is the default resolv.conf path used by plugins which
+// automatically populate their values from the host's resolver configuration.
+const defaultResolvConf = "/etc/resolv.conf"
+
+// checkResolvConf verifies that a resolv.conf path is only specified when
+// a plugin is configured for "auto" mode, and sets the default path if needed.
+func checkResolvConf(auto bool, path *string) error {
+	switch {
+	case !auto && *path != "":
+		return errors.New(`resolv_conf may only be set when using "auto" mode`)
+	case auto && *path == "":
+		*path = defaultResolvConf
+	}
+
+	return nil
+}
+
+// A Route configures a NDP Route Information option.
+type Route struct {
+	Prefix     *net.IPNet
+	Preference ndp.RouterSelectionPreference
+	Lifetime   time.Duration
+}
+
+// NewRoute creates a Route with default values.
+func NewRoute() *Route {
+	return &Route{
+		Preference: ndp.Medium,
+		Lifetime:   DurationAuto,
+	}
+}
+
+// Name implements Plugin.
+func (r *Route) Name() string { return "route" }
+
+// String implements Plugin.
+func (r *Route) String() string {
+	return fmt.Sprintf("%s, preference: %s, lifetime: %s",
+		r.Prefix, r.Preference, r.Lifetime)
+}
+
+// Decode implements Plugin.
+func (r *Route) Decode(md toml.MetaData, m map[string]toml.Primitive) error {
+	for k := range m {
+		var v value
+		if err := md.PrimitiveDecode(m[k], &v.v); err != nil {
+			return err
+		}
+
+		switch k {
+		case "name":
+			// Already handled.
+		case "lifetime":
+			r.Lifetime = v.Duration()
+		case "preference":
+			r.Preference = v.Preference()
+		case "prefix":
+			r.Prefix = v.IPNet()
+		default:
+			return fmt.Errorf("invalid key %q", k)
+		}
+
+		if err := v.Err(); err != nil {
+			return fmt.Errorf("parsing key %q: %v", k, err)
+		}
+	}
+
+	if r.Prefix == nil {
+		return errors.New("prefix must not be empty")
+	}
+
+	return nil
+}
+
+// A PREF64 configures a NDP PREF64 option, as described in RFC 8781.
+type PREF64 struct {
+	Prefix   *net.IPNet
+	Lifetime time.Duration
+}
+
+// NewPREF64 creates a PREF64 with default values.
+func NewPREF64() *PREF64 {
+	return &PREF64{Lifetime: DurationAuto}
+}
+
+// Name implements Plugin.
+func (p *PREF64) Name() string { return "pref64" }
+
+// String implements Plugin.
+func (p *PREF64) String() string {
+	return fmt.Sprintf("%s, lifetime: %s", p.Prefix, p.Lifetime)
+}
+
+// Decode implements Plugin.
+func (p *PREF64) Decode(md toml.MetaData, m map[string]toml.Primitive) error {
+	for k := range m {
+		var v value
+		if err := md.PrimitiveDecode(m[k], &v.v); err != nil {
+			return err
+		}
+
+		switch k {
+		case "name":
+			// Already handled.
+		case "lifetime":
+			p.Lifetime = v.Duration()
+		case "prefix":
+			p.Prefix = v.IPNet()
+		default:
+			return fmt.Errorf("invalid key %q", k)
+		}
+
+		if err := v.Err(); err != nil {
+			return fmt.Errorf("parsing key %q: %v", k, err)
+		}
+	}
+
+	return p.validate()
+}
+
+// maxPREF64Lifetime is the maximum lifetime which can be represented by the
+// 13-bit scaled lifetime field of a PREF64 option.
+const maxPREF64Lifetime = 8191 * 8 * time.Second
+
+// validate verifies that a PREF64 is valid.
+func (p *PREF64) validate() error {
+	if p.Prefix == nil {
+		return errors.New("prefix must not be empty")
+	}
+
+	// See: https://tools.ietf.org/html/rfc8781#section-4.
+	switch length, _ := p.Prefix.Mask.Size(); length {
+	case 32, 40, 48, 56, 64, 96:
+	default:
+		return fmt.Errorf("prefix length %d must be one of: 32, 40, 48, 56, 64, 96", length)
+	}
+
+	if p.Lifetime != DurationAuto && p.Lifetime > maxPREF64Lifetime {
+		return fmt.Errorf("lifetime of %s exceeds maximum of %s", p.Lifetime, maxPREF64Lifetime)
+	}
+
+	return nil
+}
+
+// A CaptivePortal configures a NDP Captive-Portal option, as described in
+// RFC 8910.
+type CaptivePortal struct {
+	URI string
+}
+
+// capportUnrestricted is a special captive portal URI which indicates that
+// there is no captive portal on this network, per RFC 8910, section 2.
+const capportUnrestricted = "urn:ietf:params:capport:unrestricted"
+
+// Name implements Plugin.
+func (c *CaptivePortal) Name() string { return "captive_portal" }
+
+// String implements Plugin.
+func (c *CaptivePortal) String() string { return fmt.Sprintf("URI: %q", c.URI) }
+
+// Decode implements Plugin.
+func (c *CaptivePortal) Decode(md toml.MetaData, m map[string]toml.Primitive) error {
+	for k := range m {
+		var v value
+		if err := md.PrimitiveDecode(m[k], &v.v); err != nil {
+			return err
+		}
+
+		switch k {
+		case "name":
+			// Already handled.
+		case "uri":
+			c.URI = v.string()
+		default:
+			return fmt.Errorf("invalid key %q", k)
+		}
+
+		if err := v.Err(); err != nil {
+			return fmt.Errorf("parsing key %q: %v", k, err)
+		}
+	}
+
+	return c.validate()
+}
+
+// validate verifies that a CaptivePortal is valid.
+func (c *CaptivePortal) validate() error {
+	if c.URI == "" {
+		return errors.New("URI must not be empty")
+	}
+
+	// The option length is specified in units of 8 octets and also includes
+	// the type and length fields.
+	if l := len(c.URI); l > 255*8-2 {
+		return fmt.Errorf("URI length of %d bytes is too long", l)
+	}
+
+	if c.URI == capportUnrestricted {
+		return nil
+	}
+
+	// Per RFC 8908, the API URI must use HTTPS.
+	u, err := url.Parse(c.URI)
+	if err != nil {
+		return fmt.Errorf("invalid URI: %v", err)
+	}
+
+	if !u.IsAbs() || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("URI %q must be an absolute https URL or %q", c.URI, capportUnrestricted)
+	}
+
+	return nil
+}
+
+// A Raw configures an arbitrary NDP option, which can be used to serve options
+// which are not otherwise supported by CoreRAD.
+type Raw struct {
+	Type  uint8
+	Value []byte
+}
+
+// Name implements Plugin.
+func (r *Raw) Name() string { return "raw" }
+
+// String implements Plugin.
+func (r *Raw) String() string {
+	return fmt.Sprintf("type: %d, value: %x", r.Type, r.Value)
+}
+
+// Decode implements Plugin.
+func (r *Raw) Decode(md toml.MetaData, m map[string]toml.Primitive) error {
+	var (
+		typ      = -1
+		raw      string
+		encoding = "hex"
+	)
+
+	for k := range m {
+		var v value
+		if err := md.PrimitiveDecode(m[k], &v.v); err != nil {
+			return err
+		}
+
+		switch k {
+		case "name":
+			// Already handled.
+		case "encoding":
+			encoding = v.string()
+		case "type":
+			typ = v.Int(0, 255)
+		case "value":
+			raw = v.string()
+		default:
+			return fmt.Errorf("invalid key %q", k)
+		}
+
+		if err := v.Err(); err != nil {
+			return fmt.Errorf("parsing key %q: %v", k, err)
+		}
+	}
+
+	if typ == -1 {
+		return errors.New("type must be specified")
+	}
+	r.Type = uint8(typ)
+
+	// The value encoding can only be interpreted once all keys are known.
+	var err error
+	switch encoding {
+	case "hex":
+		r.Value, err = hex.DecodeString(raw)
+	case "base64":
+		r.Value, err = base64.StdEncoding.DecodeString(raw)
+	default:
+		return fmt.Errorf("encoding %q must be one of: hex, base64", encoding)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s value: %v", encoding, err)
+	}
+
+	return r.validate()
+}
+
+// validate verifies that a Raw is valid.
+func (r *Raw) validate() error {
+	// The option length is specified in units of 8 octets and also includes
+	// the type and length fields, so the value must fill those units exactly.
+	l := 2 + len(r.Value)
+	if l%8 != 0 || l/8 > 255 {
+		return fmt.Errorf("value length of %d bytes plus 2 bytes for type and length must be a multiple of 8 bytes, up to %d bytes", len(r.Value), 255*8)
+	}
+
 	return nil
 }
@@ -14,6 +14,7 @@
 package config
 
 import (
+	"fmt"
 	"net"
 	"strings"
 	"testing"
@@ -24,6 +25,327 @@ import (
 	"github.com/mdlayher/ndp"
 )
 
+func TestCaptivePortalDecode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		s    string
+		c    *CaptivePortal
+		ok   bool
+	}{
+		{
+			name: "unknown key",
+			s: `
+			name = "captive_portal"
+			bad = true
+			`,
+		},
+		{
+			name: "no URI",
+			s: `
+			name = "captive_portal"
+			`,
+		},
+		{
+			name: "bad URI type",
+			s: `
+			name = "captive_portal"
+			uri = 1
+			`,
+		},
+		{
+			name: "bad URI relative",
+			s: `
+			name = "captive_portal"
+			uri = "/capport"
+			`,
+		},
+		{
+			name: "bad URI HTTP",
+			s: `
+			name = "captive_portal"
+			uri = "http://example.com/capport"
+			`,
+		},
+		{
+			name: "bad URI URN",
+			s: `
+			name = "captive_portal"
+			uri = "urn:ietf:params:capport:foo"
+			`,
+		},
+		{
+			name: "bad URI too long",
+			s: `
+			name = "captive_portal"
+			uri = "https://example.com/` + strings.Repeat("a", 2048) + `"
+			`,
+		},
+		{
+			name: "OK HTTPS",
+			s: `
+			name = "captive_portal"
+			uri = "https://example.com/capport"
+			`,
+			c: &CaptivePortal{
+				URI: "https://example.com/capport",
+			},
+			ok: true,
+		},
+		{
+			name: "OK unrestricted",
+			s: `
+			name = "captive_portal"
+			uri = "urn:ietf:params:capport:unrestricted"
+			`,
+			c: &CaptivePortal{
+				URI: "urn:ietf:params:capport:unrestricted",
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pluginDecode(t, tt.s, tt.ok, tt.c)
+		})
+	}
+}
+
+func TestDNRDecode(t *testing.T) {
+	t.Parallel()
+
+	// servers produces n IPv6 addresses and their TOML representation.
+	servers := func(n int) ([]net.IP, string) {
+		var (
+			ips []net.IP
+			ss  []string
+		)
+
+		for i := 0; i < n; i++ {
+			ip := mustIP(fmt.Sprintf("2001:db8::%x", i+1))
+			ips = append(ips, ip)
+			ss = append(ss, fmt.Sprintf("%q", ip))
+		}
+
+		return ips, "[" + strings.Join(ss, ", ") + "]"
+	}
+
+	// The largest number of servers which fit in the option alongside the
+	// other fields.
+	maxIPs, maxServers := servers(125)
+	_, tooManyServers := servers(126)
+
+	tests := []struct {
+		name string
+		s    string
+		d    *DNR
+		ok   bool
+	}{
+		{
+			name: "unknown key",
+			s: `
+			name = "dnr"
+			bad = true
+			`,
+		},
+		{
+			name: "no ADN",
+			s: `
+			name = "dnr"
+			servers = ["2001:db8::1"]
+			alpn = ["dot"]
+			`,
+		},
+		{
+			name: "bad ADN label",
+			s: `
+			name = "dnr"
+			authentication_domain_name = "dns..example.com"
+			servers = ["2001:db8::1"]
+			alpn = ["dot"]
+			`,
+		},
+		{
+			name: "bad ADN label too long",
+			s: `
+			name = "dnr"
+			authentication_domain_name = "` + strings.Repeat("a", 64) + `.example.com"
+			servers = ["2001:db8::1"]
+			alpn = ["dot"]
+			`,
+		},
+		{
+			name: "bad ADN too long",
+			s: `
+			name = "dnr"
+			authentication_domain_name = "` + strings.Repeat(strings.Repeat("a", 63)+".", 4) + `com"
+			servers = ["2001:db8::1"]
+			alpn = ["dot"]
+			`,
+		},
+		{
+			name: "no servers",
+			s: `
+			name = "dnr"
+			authentication_domain_name = "dns.example.com"
+			alpn = ["dot"]
+			`,
+		},
+		{
+			name: "bad servers",
+			s: `
+			name = "dnr"
+			authentication_domain_name = "dns.example.com"
+			servers = ["192.0.2.1"]
+			alpn = ["dot"]
+			`,
+		},
+		{
+			name: "no ALPN",
+			s: `
+			name = "dnr"
+			authentication_domain_name = "dns.example.com"
+			servers = ["2001:db8::1"]
+			`,
+		},
+		{
+			name: "bad ALPN empty",
+			s: `
+			name = "dnr"
+			authentication_domain_name = "dns.example.com"
+			servers = ["2001:db8::1"]
+			alpn = [""]
+			`,
+		},
+		{
+			name: "bad service priority",
+			s: `
+			name = "dnr"
+			service_priority = 0
+			authentication_domain_name = "dns.example.com"
+			servers = ["2001:db8::1"]
+			alpn = ["dot"]
+			`,
+		},
+		{
+			name: "bad port",
+			s: `
+			name = "dnr"
+			authentication_domain_name = "dns.example.com"
+			servers = ["2001:db8::1"]
+			alpn = ["dot"]
+			port = 65536
+			`,
+		},
+		{
+			name: "bad DoH path",
+			s: `
+			name = "dnr"
+			authentication_domain_name = "dns.example.com"
+			servers = ["2001:db8::1"]
+			alpn = ["h2"]
+			doh_path = "/dns-query"
+			`,
+		},
+		{
+			name: "bad DoH path too long",
+			s: `
+			name = "dnr"
+			authentication_domain_name = "dns.example.com"
+			servers = ["2001:db8::1"]
+			alpn = ["h2"]
+			doh_path = "/` + strings.Repeat("a", 2048) + `{?dns}"
+			`,
+		},
+		{
+			name: "bad ALPN too long",
+			s: `
+			name = "dnr"
+			authentication_domain_name = "dns.example.com"
+			servers = ["2001:db8::1"]
+			alpn = ["` + strings.Repeat(strings.Repeat("a", 255)+`", "`, 8) + `dot"]
+			`,
+		},
+		{
+			name: "bad too many servers",
+			s: `
+			name = "dnr"
+			authentication_domain_name = "dns.example.com"
+			servers = ` + tooManyServers + `
+			alpn = ["dot"]
+			`,
+		},
+		{
+			name: "OK maximum servers",
+			s: `
+			name = "dnr"
+			authentication_domain_name = "dns.example.com"
+			servers = ` + maxServers + `
+			alpn = ["dot"]
+			`,
+			d: &DNR{
+				ServicePriority:          1,
+				AuthenticationDomainName: "dns.example.com",
+				Servers:                  maxIPs,
+				ALPN:                     []string{"dot"},
+				Lifetime:                 DurationAuto,
+			},
+			ok: true,
+		},
+		{
+			name: "OK defaults",
+			s: `
+			name = "dnr"
+			authentication_domain_name = "dns.example.com"
+			servers = ["2001:db8::1"]
+			alpn = ["dot"]
+			`,
+			d: &DNR{
+				ServicePriority:          1,
+				AuthenticationDomainName: "dns.example.com",
+				Servers:                  []net.IP{mustIP("2001:db8::1")},
+				ALPN:                     []string{"dot"},
+				Lifetime:                 DurationAuto,
+			},
+			ok: true,
+		},
+		{
+			name: "OK explicit",
+			s: `
+			name = "dnr"
+			service_priority = 10
+			authentication_domain_name = "dns.example.com."
+			servers = ["2001:db8::1", "2001:db8::2"]
+			alpn = ["h2", "h3"]
+			port = 8443
+			doh_path = "/dns-query{?dns}"
+			lifetime = "10m"
+			`,
+			d: &DNR{
+				ServicePriority:          10,
+				AuthenticationDomainName: "dns.example.com.",
+				Servers: []net.IP{
+					mustIP("2001:db8::1"),
+					mustIP("2001:db8::2"),
+				},
+				ALPN:     []string{"h2", "h3"},
+				Port:     8443,
+				DoHPath:  "/dns-query{?dns}",
+				Lifetime: 10 * time.Minute,
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pluginDecode(t, tt.s, tt.ok, tt.d)
+		})
+	}
+}
+
 func TestDNSSLDecode(t *testing.T) {
 	t.Parallel()
 
@@ -67,6 +389,63 @@ func TestDNSSLDecode(t *testing.T) {
 			},
 			ok: true,
 		},
+		{
+			name: "bad decrement auto",
+			s: `
+			name = "dnssl"
+			domain_names = ["foo.example.com"]
+			lifetime = "auto"
+			decrement_lifetimes = true
+			`,
+		},
+		{
+			name: "OK decrement",
+			s: `
+			name = "dnssl"
+			domain_names = ["foo.example.com"]
+			lifetime = "1h"
+			decrement_lifetimes = true
+			`,
+			d: &DNSSL{
+				Lifetime:           time.Hour,
+				DomainNames:        []string{"foo.example.com"},
+				DecrementLifetimes: true,
+			},
+			ok: true,
+		},
+		{
+			name: "bad resolv.conf static",
+			s: `
+			name = "dnssl"
+			domain_names = ["foo.example.com"]
+			resolv_conf = "/etc/resolv.conf"
+			`,
+		},
+		{
+			name: "OK auto",
+			s: `
+			name = "dnssl"
+			domain_names = "auto"
+			lifetime = "30s"
+			`,
+			d: &DNSSL{
+				Lifetime:   30 * time.Second,
+				ResolvConf: "/etc/resolv.conf",
+			},
+			ok: true,
+		},
+		{
+			name: "OK auto resolv.conf",
+			s: `
+			name = "dnssl"
+			domain_names = "auto"
+			resolv_conf = "/run/systemd/resolve/resolv.conf"
+			`,
+			d: &DNSSL{
+				ResolvConf: "/run/systemd/resolve/resolv.conf",
+			},
+			ok: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -76,6 +455,73 @@ func TestDNSSLDecode(t *testing.T) {
 	}
 }
 
+func TestHomeAgentDecode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		s    string
+		h    *HomeAgent
+		ok   bool
+	}{
+		{
+			name: "unknown key",
+			s: `
+			name = "home_agent"
+			bad = true
+			`,
+		},
+		{
+			name: "bad preference",
+			s: `
+			name = "home_agent"
+			preference = 32768
+			`,
+		},
+		{
+			name: "bad lifetime zero",
+			s: `
+			name = "home_agent"
+			lifetime = "0s"
+			`,
+		},
+		{
+			name: "bad lifetime too long",
+			s: `
+			name = "home_agent"
+			lifetime = "65536s"
+			`,
+		},
+		{
+			name: "OK defaults",
+			s: `
+			name = "home_agent"
+			`,
+			h:  NewHomeAgent(),
+			ok: true,
+		},
+		{
+			name: "OK explicit",
+			s: `
+			name = "home_agent"
+			preference = -10
+			lifetime = "30m"
+			`,
+			h: &HomeAgent{
+				Preference: -10,
+				Lifetime:   30 * time.Minute,
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pluginDecode(t, tt.s, tt.ok, tt.h)
+		})
+	}
+}
+
 func TestPrefixDecode(t *testing.T) {
 	t.Parallel()
 
@@ -85,6 +531,7 @@ func TestPrefixDecode(t *testing.T) {
 		Autonomous:        true,
 		PreferredLifetime: 7 * 24 * time.Hour,
 		ValidLifetime:     30 * 24 * time.Hour,
+		DeprecationPeriod: 2 * time.Hour,
 	}
 
 	tests := []struct {
@@ -174,6 +621,7 @@ func TestPrefixDecode(t *testing.T) {
 				Autonomous:        true,
 				PreferredLifetime: ndp.Infinity,
 				ValidLifetime:     ndp.Infinity,
+				DeprecationPeriod: 2 * time.Hour,
 			},
 			ok: true,
 		},
@@ -184,24 +632,198 @@ func TestPrefixDecode(t *testing.T) {
 			prefix = "::/64"
 			autonomous = false
 			on_link = true
+			router_address = true
+			decrement_lifetimes = true
 			preferred_lifetime = "30s"
 			valid_lifetime = "60s"
 			`,
 			p: &Prefix{
-				Prefix:            mustCIDR("::/64"),
-				OnLink:            true,
-				PreferredLifetime: 30 * time.Second,
-				ValidLifetime:     60 * time.Second,
+				Prefix:             mustCIDR("::/64"),
+				OnLink:             true,
+				RouterAddress:      true,
+				PreferredLifetime:  30 * time.Second,
+				ValidLifetime:      60 * time.Second,
+				DeprecationPeriod:  60 * time.Second,
+				DecrementLifetimes: true,
 			},
 			ok: true,
 		},
+		{
+			name: "bad subnet without delegated prefix",
+			s: `
+			name = "prefix"
+			prefix = "::/64"
+			subnet = 1
+			`,
+		},
+		{
+			name: "bad subnet",
+			s: `
+			name = "prefix"
+			prefix = "::/64"
+			delegated_prefix_file = "/run/pd.json"
+			subnet = -1
+			`,
+		},
+		{
+			name: "bad delegated prefix static",
+			s: `
+			name = "prefix"
+			prefix = "2001:db8::/64"
+			delegated_prefix_file = "/run/pd.json"
+			`,
+		},
+		{
+			name: "OK delegated prefix",
+			s: `
+			name = "prefix"
+			prefix = "::/64"
+			delegated_prefix_file = "/run/pd.json"
+			subnet = 2
+			`,
+			p: &Prefix{
+				Prefix:              mustCIDR("::/64"),
+				OnLink:              true,
+				Autonomous:          true,
+				PreferredLifetime:   7 * 24 * time.Hour,
+				ValidLifetime:       30 * 24 * time.Hour,
+				DeprecationPeriod:   2 * time.Hour,
+				DelegatedPrefixFile: "/run/pd.json",
+				Subnet:              2,
+			},
+			ok: true,
+		},
+		{
+			name: "bad deprecation period",
+			s: `
+			name = "prefix"
+			prefix = "::/64"
+			valid_lifetime = "60s"
+			deprecation_period = "61s"
+			`,
+		},
+		{
+			name: "OK deprecation period",
+			s: `
+			name = "prefix"
+			prefix = "::/64"
+			deprecation_period = "0s"
+			`,
+			p: &Prefix{
+				Prefix:            mustCIDR("::/64"),
+				OnLink:            true,
+				Autonomous:        true,
+				PreferredLifetime: 7 * 24 * time.Hour,
+				ValidLifetime:     30 * 24 * time.Hour,
+			},
+			ok: true,
+		},
+		{
+			name: "bad scope link-local",
+			s: `
+			name = "prefix"
+			prefix = "::/64"
+			scope = "link-local"
+			`,
+		},
+		{
+			name: "bad include",
+			s: `
+			name = "prefix"
+			prefix = "::/64"
+			include = ["2001:db8::1"]
+			`,
+		},
+		{
+			name: "bad exclude all",
+			s: `
+			name = "prefix"
+			prefix = "::/64"
+			exclude = ["::/0"]
+			`,
+		},
+		{
+			name: "bad static excluded",
+			s: `
+			name = "prefix"
+			prefix = "2001:db8:1::/64"
+			exclude = ["2001:db8::/32"]
+			`,
+		},
+		{
+			name: "bad static not included",
+			s: `
+			name = "prefix"
+			prefix = "2001:db8:1::/64"
+			include = ["2001:db8:2::/48"]
+			`,
+		},
+		{
+			name: "bad static scope",
+			s: `
+			name = "prefix"
+			prefix = "fd00::/64"
+			scope = "global"
+			`,
+		},
+		{
+			name: "OK filters",
+			s: `
+			name = "prefix"
+			prefix = "::/64"
+			scope = "global"
+			include = ["2001:db8::/32"]
+			exclude = ["2001:db8:ffff::/48"]
+			`,
+			p: &Prefix{
+				Prefix:            mustCIDR("::/64"),
+				OnLink:            true,
+				Autonomous:        true,
+				PreferredLifetime: 7 * 24 * time.Hour,
+				ValidLifetime:     30 * 24 * time.Hour,
+				DeprecationPeriod: 2 * time.Hour,
+				Scope:             ScopeGlobal,
+				Include:           []*net.IPNet{mustCIDR("2001:db8::/32")},
+				Exclude:           []*net.IPNet{mustCIDR("2001:db8:ffff::/48")},
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pluginDecode(t, tt.s, tt.ok, tt.p)
+		})
+	}
+}
+
+func TestPrefixMatch(t *testing.T) {
+	p := &Prefix{
+		Prefix:  mustCIDR("::/64"),
+		Scope:   ScopeGlobal,
+		Include: []*net.IPNet{mustCIDR("2001:db8::/32")},
+		Exclude: []*net.IPNet{mustCIDR("2001:db8:ffff::/48")},
+	}
+
+	tests := []struct {
+		prefix string
+		ok     bool
+	}{
+		{prefix: "2001:db8:1::/64", ok: true},
+		{prefix: "2001:db8:ffff:1::/64"},
+		{prefix: "2001:db9::/64"},
+		{prefix: "2001:db8::/31"},
+		{prefix: "fd00::/64"},
+		{prefix: "fe80::/64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if diff := cmp.Diff(tt.ok, p.Match(mustCIDR(tt.prefix))); diff != "" {
+				t.Fatalf("unexpected match (-want +got):\n%s", diff)
+			}
+		})
 	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			pluginDecode(t, tt.s, tt.ok, tt.p)
-		})
-	}
 }
 
 func TestMTUDecode(t *testing.T) {
@@ -245,6 +867,212 @@ func TestMTUDecode(t *testing.T) {
 	}
 }
 
+func TestPREF64Decode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		s    string
+		p    *PREF64
+		ok   bool
+	}{
+		{
+			name: "unknown key",
+			s: `
+			name = "pref64"
+			bad = true
+			`,
+		},
+		{
+			name: "no prefix",
+			s: `
+			name = "pref64"
+			`,
+		},
+		{
+			name: "bad prefix length",
+			s: `
+			name = "pref64"
+			prefix = "64:ff9b::/80"
+			`,
+		},
+		{
+			name: "bad lifetime",
+			s: `
+			name = "pref64"
+			prefix = "64:ff9b::/96"
+			lifetime = "foo"
+			`,
+		},
+		{
+			name: "bad infinite lifetime",
+			s: `
+			name = "pref64"
+			prefix = "64:ff9b::/96"
+			lifetime = "infinite"
+			`,
+		},
+		{
+			name: "bad lifetime too long",
+			s: `
+			name = "pref64"
+			prefix = "64:ff9b::/96"
+			lifetime = "65529s"
+			`,
+		},
+		{
+			name: "OK defaults",
+			s: `
+			name = "pref64"
+			prefix = "64:ff9b::/96"
+			`,
+			p: &PREF64{
+				Prefix:   mustCIDR("64:ff9b::/96"),
+				Lifetime: DurationAuto,
+			},
+			ok: true,
+		},
+		{
+			name: "OK explicit",
+			s: `
+			name = "pref64"
+			prefix = "2001:db8::/32"
+			lifetime = "10m"
+			`,
+			p: &PREF64{
+				Prefix:   mustCIDR("2001:db8::/32"),
+				Lifetime: 10 * time.Minute,
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pluginDecode(t, tt.s, tt.ok, tt.p)
+		})
+	}
+}
+
+func TestRawDecode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		s    string
+		r    *Raw
+		ok   bool
+	}{
+		{
+			name: "unknown key",
+			s: `
+			name = "raw"
+			bad = true
+			`,
+		},
+		{
+			name: "no type",
+			s: `
+			name = "raw"
+			value = "000102030405"
+			`,
+		},
+		{
+			name: "bad type",
+			s: `
+			name = "raw"
+			type = 256
+			value = "000102030405"
+			`,
+		},
+		{
+			name: "bad encoding",
+			s: `
+			name = "raw"
+			type = 253
+			value = "000102030405"
+			encoding = "foo"
+			`,
+		},
+		{
+			name: "bad hex",
+			s: `
+			name = "raw"
+			type = 253
+			value = "zz"
+			`,
+		},
+		{
+			name: "bad base64",
+			s: `
+			name = "raw"
+			type = 253
+			value = "!"
+			encoding = "base64"
+			`,
+		},
+		{
+			name: "no value",
+			s: `
+			name = "raw"
+			type = 253
+			`,
+		},
+		{
+			name: "bad value length",
+			s: `
+			name = "raw"
+			type = 253
+			value = "0001020304"
+			`,
+		},
+		{
+			name: "bad value too long",
+			s: `
+			name = "raw"
+			type = 253
+			value = "` + strings.Repeat("00", 255*8) + `"
+			`,
+		},
+		{
+			name: "OK hex",
+			s: `
+			name = "raw"
+			type = 253
+			value = "000102030405"
+			`,
+			r: &Raw{
+				Type:  253,
+				Value: []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05},
+			},
+			ok: true,
+		},
+		{
+			name: "OK base64",
+			s: `
+			name = "raw"
+			type = 254
+			value = "AAECAwQFBgcICQoLDA0="
+			encoding = "base64"
+			`,
+			r: &Raw{
+				Type: 254,
+				Value: []byte{
+					0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06,
+					0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d,
+				},
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pluginDecode(t, tt.s, tt.ok, tt.r)
+		})
+	}
+}
+
 func TestRDNSSDecode(t *testing.T) {
 	t.Parallel()
 
@@ -291,6 +1119,186 @@ func TestRDNSSDecode(t *testing.T) {
 			},
 			ok: true,
 		},
+		{
+			name: "bad decrement auto",
+			s: `
+			name = "rdnss"
+			servers = ["2001:db8::1"]
+			lifetime = "auto"
+			decrement_lifetimes = true
+			`,
+		},
+		{
+			name: "OK decrement",
+			s: `
+			name = "rdnss"
+			servers = ["2001:db8::1"]
+			lifetime = "1h"
+			decrement_lifetimes = true
+			`,
+			r: &RDNSS{
+				Lifetime:           time.Hour,
+				Servers:            []net.IP{mustIP("2001:db8::1")},
+				DecrementLifetimes: true,
+			},
+			ok: true,
+		},
+		{
+			name: "bad servers string",
+			s: `
+			name = "rdnss"
+			servers = "foo"
+			`,
+		},
+		{
+			name: "bad scope",
+			s: `
+			name = "rdnss"
+			servers = ["::"]
+			scope = "foo"
+			`,
+		},
+		{
+			name: "bad scope no wildcard",
+			s: `
+			name = "rdnss"
+			servers = ["2001:db8::1"]
+			scope = "global"
+			`,
+		},
+		{
+			name: "OK wildcard",
+			s: `
+			name = "rdnss"
+			servers = ["::", "2001:db8::1"]
+			scope = "unique-local"
+			lifetime = "30s"
+			`,
+			r: &RDNSS{
+				Lifetime: 30 * time.Second,
+				Servers: []net.IP{
+					net.IPv6unspecified,
+					mustIP("2001:db8::1"),
+				},
+				Scope: ScopeUniqueLocal,
+			},
+			ok: true,
+		},
+		{
+			name: "bad resolv.conf static",
+			s: `
+			name = "rdnss"
+			servers = ["2001:db8::1"]
+			resolv_conf = "/etc/resolv.conf"
+			`,
+		},
+		{
+			name: "OK auto",
+			s: `
+			name = "rdnss"
+			servers = "auto"
+			lifetime = "30s"
+			`,
+			r: &RDNSS{
+				Lifetime:   30 * time.Second,
+				ResolvConf: "/etc/resolv.conf",
+			},
+			ok: true,
+		},
+		{
+			name: "OK auto resolv.conf",
+			s: `
+			name = "rdnss"
+			servers = "auto"
+			resolv_conf = "/run/systemd/resolve/resolv.conf"
+			`,
+			r: &RDNSS{
+				ResolvConf: "/run/systemd/resolve/resolv.conf",
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pluginDecode(t, tt.s, tt.ok, tt.r)
+		})
+	}
+}
+
+func TestRouteDecode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		s    string
+		r    *Route
+		ok   bool
+	}{
+		{
+			name: "unknown key",
+			s: `
+			name = "route"
+			bad = true
+			`,
+		},
+		{
+			name: "no prefix",
+			s: `
+			name = "route"
+			`,
+		},
+		{
+			name: "bad prefix",
+			s: `
+			name = "route"
+			prefix = "foo"
+			`,
+		},
+		{
+			name: "bad preference",
+			s: `
+			name = "route"
+			prefix = "2001:db8::/32"
+			preference = "foo"
+			`,
+		},
+		{
+			name: "bad lifetime",
+			s: `
+			name = "route"
+			prefix = "2001:db8::/32"
+			lifetime = "foo"
+			`,
+		},
+		{
+			name: "OK defaults",
+			s: `
+			name = "route"
+			prefix = "::/64"
+			`,
+			r: &Route{
+				Prefix:     mustCIDR("::/64"),
+				Preference: ndp.Medium,
+				Lifetime:   DurationAuto,
+			},
+			ok: true,
+		},
+		{
+			name: "OK explicit",
+			s: `
+			name = "route"
+			prefix = "fd00::/48"
+			preference = "high"
+			lifetime = "infinite"
+			`,
+			r: &Route{
+				Prefix:     mustCIDR("fd00::/48"),
+				Preference: ndp.High,
+				Lifetime:   ndp.Infinity,
+			},
+			ok: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,72 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net"
+)
+
+// A Scope selects IPv6 addresses by their scope.
+type Scope int
+
+// Possible Scope values.
+const (
+	ScopeAny Scope = iota
+	ScopeLinkLocal
+	ScopeUniqueLocal
+	ScopeGlobal
+)
+
+// ula is the IPv6 Unique Local Address prefix, as described in RFC 4193.
+var ula = &net.IPNet{
+	IP:   net.IP{0xfc, 0x00, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+	Mask: net.CIDRMask(7, 128),
+}
+
+// Match reports whether ip is an IPv6 unicast address within Scope s.
+func (s Scope) Match(ip net.IP) bool {
+	if ip.To16() == nil || ip.To4() != nil {
+		return false
+	}
+
+	switch s {
+	case ScopeAny:
+		return ip.IsLinkLocalUnicast() || ip.IsGlobalUnicast()
+	case ScopeLinkLocal:
+		return ip.IsLinkLocalUnicast()
+	case ScopeUniqueLocal:
+		return ula.Contains(ip)
+	case ScopeGlobal:
+		return ip.IsGlobalUnicast() && !ula.Contains(ip)
+	default:
+		panic(fmt.Sprintf("config: invalid Scope: %d", s))
+	}
+}
+
+// String returns the string representation of a Scope.
+func (s Scope) String() string {
+	switch s {
+	case ScopeAny:
+		return "any"
+	case ScopeLinkLocal:
+		return "link-local"
+	case ScopeUniqueLocal:
+		return "unique-local"
+	case ScopeGlobal:
+		return "global"
+	default:
+		return fmt.Sprintf("Scope(%d)", s)
+	}
+}
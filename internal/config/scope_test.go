@@ -0,0 +1,71 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"net"
+	"testing"
+)
+
+func TestScopeMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		ip                               net.IP
+		any, linkLocal, uniqueLocal, gua bool
+	}{
+		{
+			ip: net.IPv4(192, 0, 2, 1),
+		},
+		{
+			ip: net.IPv6loopback,
+		},
+		{
+			ip: net.IPv6linklocalallnodes,
+		},
+		{
+			ip:        mustIP("fe80::1"),
+			any:       true,
+			linkLocal: true,
+		},
+		{
+			ip:          mustIP("fd00::1"),
+			any:         true,
+			uniqueLocal: true,
+		},
+		{
+			ip:  mustIP("2001:db8::1"),
+			any: true,
+			gua: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip.String(), func(t *testing.T) {
+			for _, s := range []struct {
+				s    Scope
+				want bool
+			}{
+				{s: ScopeAny, want: tt.any},
+				{s: ScopeLinkLocal, want: tt.linkLocal},
+				{s: ScopeUniqueLocal, want: tt.uniqueLocal},
+				{s: ScopeGlobal, want: tt.gua},
+			} {
+				if got := s.s.Match(tt.ip); got != s.want {
+					t.Fatalf("unexpected match for scope %s: %v", s.s, got)
+				}
+			}
+		})
+	}
+}
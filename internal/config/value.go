@@ -94,6 +94,29 @@ func (v *value) IPNet() *net.IPNet {
 	return cidr
 }
 
+// IPNetSlice interprets the value as a []*net.IPNet composed of IPv6 CIDR
+// prefixes.
+func (v *value) IPNetSlice() []*net.IPNet {
+	ss := v.StringSlice()
+	if v.err != nil {
+		return nil
+	}
+
+	ipns := make([]*net.IPNet, 0, len(ss))
+	for _, s := range ss {
+		vv := value{v: s}
+		ipn := vv.IPNet()
+		if err := vv.Err(); err != nil {
+			v.err = err
+			return nil
+		}
+
+		ipns = append(ipns, ipn)
+	}
+
+	return ipns
+}
+
 // IPSlice interprets the value as a []net.IP composed of IPv6 addresses.
 func (v *value) IPSlice() []net.IP {
 	ss := v.StringSlice()
@@ -115,6 +138,14 @@ func (v *value) IPSlice() []net.IP {
 	return ips
 }
 
+// Auto reports whether the value is the string "auto", which indicates that
+// a value should be computed by CoreRAD. Auto does not set an error for other
+// values so they may be interpreted by another method.
+func (v *value) Auto() bool {
+	s, ok := v.v.(string)
+	return ok && s == "auto"
+}
+
 // Bool interprets the value as a bool.
 func (v *value) Bool() bool {
 	b, ok := v.v.(bool)
@@ -152,6 +183,49 @@ func (v *value) Int(min, max int) int {
 	return i
 }
 
+// Preference interprets the value as a NDP router selection preference.
+func (v *value) Preference() ndp.RouterSelectionPreference {
+	s := v.string()
+	if v.err != nil {
+		return 0
+	}
+
+	// Values are specified in RFC 4191, section 2.1.
+	switch s {
+	case "low":
+		return ndp.Low
+	case "medium", "":
+		return ndp.Medium
+	case "high":
+		return ndp.High
+	}
+
+	v.err = fmt.Errorf("preference %q must be one of: low, medium, high", s)
+	return 0
+}
+
+// Scope interprets the value as an IPv6 address Scope.
+func (v *value) Scope() Scope {
+	s := v.string()
+	if v.err != nil {
+		return 0
+	}
+
+	switch s {
+	case "any", "":
+		return ScopeAny
+	case "link-local":
+		return ScopeLinkLocal
+	case "unique-local":
+		return ScopeUniqueLocal
+	case "global":
+		return ScopeGlobal
+	}
+
+	v.err = fmt.Errorf("scope %q must be one of: any, link-local, unique-local, global", s)
+	return 0
+}
+
 // StringSlice interprets the value as a []string.
 func (v *value) StringSlice() []string {
 	vs, ok := v.v.([]interface{})
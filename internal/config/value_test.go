@@ -32,6 +32,24 @@ func Test_value(t *testing.T) {
 		fn       func(v *value) interface{}
 		ok       bool
 	}{
+		{
+			name: "OK Auto",
+			fn: func(v *value) interface{} {
+				return v.Auto()
+			},
+			in:   "auto",
+			want: true,
+			ok:   true,
+		},
+		{
+			name: "OK not Auto",
+			fn: func(v *value) interface{} {
+				return v.Auto()
+			},
+			in:   []interface{}{"2001:db8::1"},
+			want: false,
+			ok:   true,
+		},
 		{
 			name: "bad bool",
 			fn: func(v *value) interface{} {
@@ -221,6 +239,61 @@ func Test_value(t *testing.T) {
 			},
 			ok: true,
 		},
+		{
+			name: "bad Preference type",
+			fn: func(v *value) interface{} {
+				return v.Preference()
+			},
+			in: 1,
+		},
+		{
+			name: "bad Preference string",
+			fn: func(v *value) interface{} {
+				return v.Preference()
+			},
+			in: "foo",
+		},
+		{
+			name: "OK Preference",
+			fn: func(v *value) interface{} {
+				return v.Preference()
+			},
+			in:   "high",
+			want: ndp.High,
+			ok:   true,
+		},
+		{
+			name: "OK empty Preference",
+			fn: func(v *value) interface{} {
+				return v.Preference()
+			},
+			in:   "",
+			want: ndp.Medium,
+			ok:   true,
+		},
+		{
+			name: "bad Scope type",
+			fn: func(v *value) interface{} {
+				return v.Scope()
+			},
+			in: 1,
+		},
+		{
+			name: "bad Scope string",
+			fn: func(v *value) interface{} {
+				return v.Scope()
+			},
+			in: "foo",
+		},
+		{
+			name: "OK Scope",
+			fn: func(v *value) interface{} {
+				return v.Scope()
+			},
+			in:   "link-local",
+			want: ScopeLinkLocal,
+			ok:   true,
+		},
 		{
 			name: "bad StringSlice array",
 			fn: func(v *value) interface{} {
@@ -0,0 +1,144 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package corerad
+
+import (
+	"net"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// ifaFlags is the IFA_FLAGS rtnetlink attribute, which carries the full set
+// of address flags on newer kernels.
+const ifaFlags = 8
+
+// interfaceAddrs fetches the IPv6 addresses for an interface on Linux systems,
+// skipping addresses which are tentative, deprecated, or failed duplicate
+// address detection, as those must not be used to produce advertisements.
+func interfaceAddrs(ifi *net.Interface) ([]net.Addr, error) {
+	ias, err := dumpAddrs(ifi)
+	if err != nil {
+		return nil, err
+	}
+
+	const skip = unix.IFA_F_TENTATIVE | unix.IFA_F_DEPRECATED | unix.IFA_F_DADFAILED
+
+	var addrs []net.Addr
+	for _, ia := range ias {
+		if ia.Flags&skip != 0 {
+			continue
+		}
+
+		addrs = append(addrs, &net.IPNet{
+			IP:   ia.IP,
+			Mask: net.CIDRMask(ia.PrefixLength, 8*net.IPv6len),
+		})
+	}
+
+	return addrs, nil
+}
+
+// linkLocalAddrs fetches the IPv6 link-local addresses for an interface on
+// Linux systems, along with their duplicate address detection state.
+func linkLocalAddrs(ifi *net.Interface) ([]linkLocalAddr, error) {
+	ias, err := dumpAddrs(ifi)
+	if err != nil {
+		return nil, err
+	}
+
+	var addrs []linkLocalAddr
+	for _, ia := range ias {
+		if !ia.IP.IsLinkLocalUnicast() {
+			continue
+		}
+
+		addrs = append(addrs, linkLocalAddr{
+			IP:        ia.IP,
+			Tentative: ia.Flags&unix.IFA_F_TENTATIVE != 0,
+			DADFailed: ia.Flags&unix.IFA_F_DADFAILED != 0,
+		})
+	}
+
+	return addrs, nil
+}
+
+// An ifAddr is an IPv6 interface address and its rtnetlink flags.
+type ifAddr struct {
+	IP           net.IP
+	PrefixLength int
+	Flags        uint32
+}
+
+// dumpAddrs fetches all of the IPv6 addresses for an interface using
+// rtnetlink.
+func dumpAddrs(ifi *net.Interface) ([]ifAddr, error) {
+	b, err := syscall.NetlinkRIB(unix.RTM_GETADDR, unix.AF_INET6)
+	if err != nil {
+		return nil, os.NewSyscallError("netlinkrib", err)
+	}
+
+	msgs, err := syscall.ParseNetlinkMessage(b)
+	if err != nil {
+		return nil, os.NewSyscallError("parsenetlinkmessage", err)
+	}
+
+	var addrs []ifAddr
+	for _, m := range msgs {
+		if m.Header.Type != unix.RTM_NEWADDR || len(m.Data) < unix.SizeofIfAddrmsg {
+			continue
+		}
+
+		ifam := (*syscall.IfAddrmsg)(unsafe.Pointer(&m.Data[0]))
+		if int(ifam.Index) != ifi.Index || ifam.Family != unix.AF_INET6 {
+			continue
+		}
+
+		attrs, err := syscall.ParseNetlinkRouteAttr(&m)
+		if err != nil {
+			return nil, os.NewSyscallError("parsenetlinkrouteattr", err)
+		}
+
+		ia := ifAddr{
+			PrefixLength: int(ifam.Prefixlen),
+			Flags:        uint32(ifam.Flags),
+		}
+
+		for _, a := range attrs {
+			switch a.Attr.Type {
+			case unix.IFA_ADDRESS:
+				if len(a.Value) == net.IPv6len {
+					ia.IP = make(net.IP, net.IPv6len)
+					copy(ia.IP, a.Value)
+				}
+			case ifaFlags:
+				if len(a.Value) == 4 {
+					ia.Flags = *(*uint32)(unsafe.Pointer(&a.Value[0]))
+				}
+			}
+		}
+
+		if ia.IP == nil {
+			continue
+		}
+
+		addrs = append(addrs, ia)
+	}
+
+	return addrs, nil
+}
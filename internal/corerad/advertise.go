@@ -22,6 +22,8 @@ import (
 	"math/rand"
 	"net"
 	"os"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/mdlayher/corerad/internal/config"
@@ -34,12 +36,31 @@ import (
 // An Advertiser sends NDP router advertisements.
 type Advertiser struct {
 	c        *ndp.Conn
+	rc       *solicitConn
 	ifi      *net.Interface
 	ip       net.IP
 	autoPrev bool
 
+	// sysctlPrev holds the previous values of sysctls changed on startup.
+	sysctlPrev []managedSysctl
+
 	cfg config.Interface
 	b   *builder
+	rl  *rateLimiter
+	fc  *forwardingCache
+
+	// stopped is set on shutdown so no more scheduled router advertisements
+	// are sent. Scheduler workers hold stopMu for reading while sending, so
+	// shutdown can wait for them to finish.
+	stopMu  sync.RWMutex
+	stopped bool
+
+	// mu serializes calls to b.Build, which resolves automatic plugin
+	// lifetimes and updates the prefix tracker, and guards last: the most
+	// recent multicast router advertisement, with its lifetimes normalized,
+	// used to detect changes.
+	mu   sync.Mutex
+	last *ndp.RouterAdvertisement
 
 	ll *log.Logger
 	mm *AdvertiserMetrics
@@ -48,6 +69,13 @@ type Advertiser struct {
 // NewAdvertiser creates an Advertiser for the specified interface. If ll is
 // nil, logs are discarded. If mm is nil, metrics are discarded.
 func NewAdvertiser(cfg config.Interface, ll *log.Logger, mm *AdvertiserMetrics) (*Advertiser, error) {
+	return newAdvertiser(context.Background(), cfg, ll, mm, newForwardingCache())
+}
+
+// newAdvertiser creates an Advertiser which tracks the IPv6 forwarding state
+// of its interface in fc. If ctx is canceled while waiting for a usable
+// link-local address, newAdvertiser returns an error.
+func newAdvertiser(ctx context.Context, cfg config.Interface, ll *log.Logger, mm *AdvertiserMetrics, fc *forwardingCache) (*Advertiser, error) {
 	if ll == nil {
 		ll = log.New(ioutil.Discard, "", 0)
 	}
@@ -60,9 +88,34 @@ func NewAdvertiser(cfg config.Interface, ll *log.Logger, mm *AdvertiserMetrics)
 		return nil, fmt.Errorf("failed to look up interface %q: %v", cfg.Name, err)
 	}
 
+	// Prepend the interface name to logs until the Advertiser is created.
+	logf := func(format string, v ...interface{}) {
+		ll.Println(ifi.Name + ": " + fmt.Sprintf(format, v...))
+	}
+
+	// Unless disabled, wait for a link-local address which has completed
+	// duplicate address detection, since advertisements must not be sent
+	// from a tentative address.
+	addr := ndp.LinkLocal
+	if cfg.LinkLocalTimeout > 0 {
+		ip, err := waitLinkLocal(
+			ctx,
+			func() ([]linkLocalAddr, error) { return linkLocalAddrs(ifi) },
+			cfg.LinkLocalTimeout,
+			logf,
+		)
+		if err != nil {
+			// Explicitly wrap this error for caller.
+			return nil, fmt.Errorf("failed to find link-local address on %q: %w", ifi.Name, err)
+		}
+
+		addr = ndp.Addr(ip.String())
+	}
+
 	// If possible, disable IPv6 autoconfiguration on this interface so that
 	// our RAs don't configure more IP addresses on this interface.
 	autoPrev, err := setIPv6Autoconf(ifi.Name, false)
+	autoSet := err == nil
 	if err != nil {
 		if errors.Is(err, os.ErrPermission) {
 			// Continue anyway but provide a hint.
@@ -73,48 +126,122 @@ func NewAdvertiser(cfg config.Interface, ll *log.Logger, mm *AdvertiserMetrics)
 		}
 	}
 
-	c, ip, err := ndp.Dial(ifi, ndp.LinkLocal)
+	// If the Advertiser cannot be created, restore the previous state of the
+	// interface so that a later attempt does not mistake CoreRAD's own
+	// changes for the interface's original state.
+	var sysctlPrev []managedSysctl
+	restore := func() {
+		if _, err := setSysctls(ifi.Name, sysctlPrev, logf, mm); err != nil {
+			logf("failed to restore sysctls: %v", err)
+		}
+
+		if !autoSet {
+			return
+		}
+
+		if _, err := setIPv6Autoconf(ifi.Name, autoPrev); err != nil {
+			logf("failed to restore IPv6 autoconfiguration: %v", err)
+		}
+	}
+
+	// Apply any declaratively configured sysctls, and keep the previous values
+	// so they can be restored.
+	sysctlPrev, err = setSysctls(ifi.Name, managedSysctls(cfg.Sysctls), logf, mm)
+	if err != nil {
+		restore()
+		return nil, fmt.Errorf("failed to set sysctls on %q: %v", ifi.Name, err)
+	}
+
+	// Don't send RAs which exceed a smaller configured IPv6 MTU.
+	if m := cfg.Sysctls.MTU; m != nil && *m < ifi.MTU {
+		ifi.MTU = *m
+	}
+
+	// The forwarding state is cached and refreshed when it changes, so that
+	// it need not be read before sending each RA.
+	forwarding, err := getIPv6Forwarding(ifi.Name)
 	if err != nil {
+		restore()
+		return nil, fmt.Errorf("failed to get IPv6 forwarding state on %q: %v", ifi.Name, err)
+	}
+	fc.Set(ifi.Name, forwarding)
+
+	c, ip, err := ndp.Dial(ifi, addr)
+	if err != nil {
+		restore()
 		// Explicitly wrap this error for caller.
 		return nil, fmt.Errorf("failed to create NDP listener: %w", err)
 	}
 
-	// We only want to accept router solicitation messages.
+	// The NDP connection is only used to send messages, so don't accept any.
 	var f ipv6.ICMPFilter
 	f.SetAll(true)
-	f.Accept(ipv6.ICMPTypeRouterSolicitation)
 
 	if err := c.SetICMPFilter(&f); err != nil {
+		_ = c.Close()
+		restore()
 		return nil, fmt.Errorf("failed to apply ICMPv6 filter: %v", err)
 	}
 
-	// We are now a router.
-	if err := c.JoinGroup(net.IPv6linklocalallrouters); err != nil {
-		return nil, fmt.Errorf("failed to join IPv6 link-local all routers multicast group: %v", err)
+	// Router solicitations are received on a separate connection which
+	// exposes the IPv6 hop limit for validation.
+	rc, err := listenSolicitations(ifi, ip)
+	if err != nil {
+		_ = c.Close()
+		restore()
+		return nil, fmt.Errorf("failed to create router solicitation listener: %w", err)
 	}
 
-	return &Advertiser{
+	a := &Advertiser{
 		c:        c,
+		rc:       rc,
 		ifi:      ifi,
 		ip:       ip,
 		autoPrev: autoPrev,
 
+		sysctlPrev: sysctlPrev,
+
 		cfg: cfg,
 		// Set up a builder to construct RAs from configuration.
 		b: &builder{
 			// Fetch the configured interface's addresses.
-			Addrs: ifi.Addrs,
+			Addrs: func() ([]net.Addr, error) { return interfaceAddrs(ifi) },
+			// Parse resolv.conf and delegated prefix files only when they
+			// change.
+			ResolvConf:      resolvConfFile(),
+			DelegatedPrefix: delegatedPrefixFile(),
+			// Decrementing lifetimes count down from the time the
+			// Advertiser is created.
+			Anchor: time.Now(),
+			// Deprecate prefixes which disappear from the interface.
+			Tracker: newPrefixTracker(),
 		},
 
+		// Limit the rate of solicited router advertisements.
+		rl: newRateLimiter(
+			cfg.SolicitationInterval,
+			cfg.SolicitationBurst,
+			cfg.SolicitationGlobalInterval,
+			maxRateLimitSources,
+		),
+
+		fc: fc,
+
 		ll: ll,
 		mm: mm,
-	}, nil
+	}
+	a.b.Logf = a.logf
+
+	return a, nil
 }
 
 // A request indicates that a router advertisement should be sent to the
 // specified IP address.
 type request struct {
 	IP net.IP
+
+	// For unsolicited multicast RAs, when the next unsolicited RA is due.
+	Next time.Time
 }
 
 // Advertise begins router solicitation and advertisement handling. Advertise
@@ -136,15 +263,25 @@ func (a *Advertiser) Advertise(ctx context.Context) error {
 		return nil
 	})
 
-	// Multicast RA generator.
+	// Multicast RA generator, which sends an RA immediately when a change
+	// is detected.
+	changeC := make(chan struct{}, 1)
 	eg.Go(func() error {
-		if err := a.multicast(ctx, reqC); err != nil {
+		if err := a.multicast(ctx, reqC, changeC); err != nil {
 			return fmt.Errorf("failed to multicast: %v", err)
 		}
 
 		return nil
 	})
 
+	// Change detector which watches for changes to this interface's
+	// addresses, forwarding state, or the configuration files read by
+	// plugins.
+	eg.Go(func() error {
+		a.detectChanges(ctx, changeC)
+		return nil
+	})
+
 	// Listener which issues RAs in response to RS messages.
 	eg.Go(func() error {
 		if err := a.listen(ctx, reqC); err != nil {
@@ -155,12 +292,29 @@ func (a *Advertiser) Advertise(ctx context.Context) error {
 	})
 
 	a.logf("initialized, sending router advertisements from %s", a.ip)
+	a.mm.setState(a.cfg.Name, stateRunning)
+	defer a.mm.setState(a.cfg.Name, stateStopped)
+
+	// Forwarding state is no longer tracked once the Advertiser stops.
+	defer a.fc.Delete(a.cfg.Name)
+
+	// Release resources and restore the interface's state even if the
+	// Advertiser failed, so the next Advertiser created by a supervisor for
+	// this interface starts from the original state.
+	runErr := eg.Wait()
+	if err := a.shutdown(); err != nil {
+		if runErr == nil {
+			return err
+		}
+
+		a.logf("failed to shut down: %v", err)
+	}
 
-	if err := eg.Wait(); err != nil {
-		return fmt.Errorf("failed to run advertiser: %v", err)
+	if runErr != nil {
+		return fmt.Errorf("failed to run advertiser: %v", runErr)
 	}
 
-	return a.shutdown()
+	return nil
 }
 
 // shutdown indicates to hosts that this host is no longer a router and restores
@@ -169,46 +323,95 @@ func (a *Advertiser) shutdown() error {
 	// In general, many of these actions are best-effort and should not halt
 	// shutdown on failure.
 
-	// Send a final router advertisement (TODO: more than one) with a router
-	// lifetime of 0 to indicate that hosts should not use this router as a
-	// default router, and then leave the all-routers group.
-	a.cfg.DefaultLifetime = 0
-	if err := a.send(net.IPv6linklocalallnodes); err != nil {
-		a.logf("failed to send final multicast router advertisement: %v", err)
-	}
+	// The scheduler may still be running workers after its context is
+	// canceled, so wait for them to finish and prevent any more from sending
+	// before the final router advertisements.
+	a.stopMu.Lock()
+	a.stopped = true
+	a.stopMu.Unlock()
 
-	if err := a.c.LeaveGroup(net.IPv6linklocalallrouters); err != nil {
-		a.logf("failed to leave IPv6 link-local all routers multicast group: %v", err)
+	// Send final router advertisements with a router lifetime of 0 to
+	// indicate that hosts should not use this router as a default router,
+	// and then leave the all-routers group.
+	a.sendFinal()
+
+	if err := a.rc.Close(); err != nil {
+		a.logf("failed to stop router solicitation listener: %v", err)
 	}
 
 	if err := a.c.Close(); err != nil {
 		a.logf("failed to stop NDP listener: %v", err)
 	}
 
-	// If possible, restore the previous IPv6 autoconfiguration state.
+	// If possible, restore the previous IPv6 autoconfiguration state. Any
+	// error is reported after the sysctls are restored as well.
+	var autoErr error
 	if _, err := setIPv6Autoconf(a.ifi.Name, a.autoPrev); err != nil {
 		if errors.Is(err, os.ErrPermission) {
 			// Continue anyway but provide a hint.
 			a.logf("permission denied while restoring IPv6 autoconfiguration state, continuing anyway (try setting CAP_NET_ADMIN)")
 			a.mm.ErrorsTotal.WithLabelValues(a.cfg.Name, "configuration").Inc()
 		} else {
-			return fmt.Errorf("failed to restore IPv6 autoconfiguration on %q: %v", a.ifi.Name, err)
+			autoErr = fmt.Errorf("failed to restore IPv6 autoconfiguration on %q: %v", a.ifi.Name, err)
 		}
 	}
 
-	return nil
+	// Restore any sysctls which were changed on startup.
+	if _, err := setSysctls(a.ifi.Name, a.sysctlPrev, a.logf, a.mm); err != nil {
+		if autoErr != nil {
+			a.logf("%v", autoErr)
+		}
+
+		return fmt.Errorf("failed to restore sysctls on %q: %v", a.ifi.Name, err)
+	}
+
+	return autoErr
+}
+
+// sendFinal sends up to maxFinalRtrAdvertisements multicast router
+// advertisements spaced by minDelayBetweenRAs, as described in:
+// https://tools.ietf.org/html/rfc4861#section-6.2.5.
+//
+// No advertisement is sent after the configured shutdown timeout would elapse.
+func (a *Advertiser) sendFinal() {
+	deadline := time.Now().Add(a.cfg.ShutdownTimeout)
+
+	for i := 0; i < maxFinalRtrAdvertisements; i++ {
+		if i > 0 {
+			if time.Now().Add(minDelayBetweenRAs).After(deadline) {
+				return
+			}
+
+			time.Sleep(minDelayBetweenRAs)
+		}
+
+		if err := a.send(net.IPv6linklocalallnodes, true); err != nil {
+			a.logf("failed to send final multicast router advertisement: %v", err)
+			a.mm.ErrorsTotal.WithLabelValues(a.cfg.Name, "transmit").Inc()
+			continue
+		}
+
+		a.mm.FinalRouterAdvertisements.WithLabelValues(a.cfg.Name).Inc()
+	}
 }
 
 // Constants taken from https://tools.ietf.org/html/rfc4861#section-10.
 const (
-	maxInitialAdvInterval = 16 * time.Second
-	maxInitialAdv         = 3
-	minDelayBetweenRAs    = 3 * time.Second
-	maxRADelay            = 500 * time.Millisecond
+	maxInitialAdvInterval     = 16 * time.Second
+	maxInitialAdv             = 3
+	maxFinalRtrAdvertisements = 3
+	minDelayBetweenRAs        = 3 * time.Second
+	maxRADelay                = 500 * time.Millisecond
 )
 
-// multicast runs a multicast advertising loop until ctx is canceled.
-func (a *Advertiser) multicast(ctx context.Context, reqC chan<- request) error {
+// changeCheckInterval is how often the router advertisement and forwarding
+// state are checked for changes which are not signaled by rtnetlink
+// notifications, such as changes to delegated prefix or resolv.conf files.
+const changeCheckInterval = 10 * time.Second
+
+// multicast runs a multicast advertising loop until ctx is canceled. When a
+// change is signaled on changeC, a multicast RA is requested immediately.
+func (a *Advertiser) multicast(ctx context.Context, reqC chan<- request, changeC <-chan struct{}) error {
 	// Initialize PRNG so we can add jitter to our unsolicited multicast RA
 	// delay times.
 	var (
@@ -225,18 +428,141 @@ func (a *Advertiser) multicast(ctx context.Context, reqC chan<- request) error {
 		default:
 		}
 
+		d := multicastDelay(prng, i, min, max)
 		reqC <- request{
-			IP: net.IPv6linklocalallnodes,
+			IP:   net.IPv6linklocalallnodes,
+			Next: time.Now().Add(d),
 		}
 
 		select {
 		case <-ctx.Done():
 			return nil
-		case <-time.After(multicastDelay(prng, i, min, max)):
+		case <-time.After(d):
+		case <-changeC:
+			// The RA has changed, so restart the initial fast advertisement
+			// phase. The scheduler still enforces minDelayBetweenRAs, per:
+			// https://tools.ietf.org/html/rfc4861#section-6.2.4.
+			i = -1
 		}
 	}
 }
 
+// detectChanges signals changeC whenever the router advertisement for this
+// interface differs from the most recent multicast router advertisement, or
+// the interface's forwarding state changes, until ctx is canceled.
+func (a *Advertiser) detectChanges(ctx context.Context, changeC chan<- struct{}) {
+	signal := func() {
+		a.mm.RouterAdvertisementChangesTotal.WithLabelValues(a.cfg.Name).Inc()
+
+		select {
+		case changeC <- struct{}{}:
+		default:
+		}
+	}
+
+	check := func() {
+		changed, err := a.changed()
+		if err != nil {
+			a.logf("failed to check for router advertisement changes: %v", err)
+			return
+		}
+		if changed {
+			signal()
+		}
+	}
+
+	// The router lifetime depends on the forwarding state, so changes must
+	// be advertised immediately.
+	refresh := func() {
+		if a.refreshForwarding() {
+			signal()
+		}
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		err := watchInterfaces(ctx, func(e watchEvent) {
+			if e.Kind != watchResync && e.Index != a.ifi.Index {
+				return
+			}
+
+			switch e.Kind {
+			case watchAddress:
+				check()
+			case watchForwarding:
+				refresh()
+			case watchResync:
+				refresh()
+				check()
+			}
+		})
+		if err != nil {
+			// Changes will still be detected periodically.
+			a.logf("failed to watch for interface changes: %v", err)
+		}
+	}()
+
+	t := time.NewTicker(changeCheckInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			refresh()
+			check()
+		}
+	}
+}
+
+// refreshForwarding updates the cached forwarding state of the interface and
+// reports whether it changed. If the state cannot be read, the previous state
+// is kept.
+func (a *Advertiser) refreshForwarding() bool {
+	forwarding, err := getIPv6Forwarding(a.ifi.Name)
+	if err != nil {
+		a.logf("failed to refresh IPv6 forwarding state, using previous state: %v", err)
+		a.mm.ErrorsTotal.WithLabelValues(a.cfg.Name, "configuration").Inc()
+		return false
+	}
+
+	if !a.fc.Set(a.cfg.Name, forwarding) {
+		return false
+	}
+
+	a.logf("IPv6 forwarding changed from %t to %t", !forwarding, forwarding)
+	return true
+}
+
+// changed reports whether the router advertisement built from the current
+// configuration and interface state differs from the most recent multicast
+// router advertisement. If so, it becomes the most recent advertisement.
+func (a *Advertiser) changed() (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ra, err := a.b.Build(a.cfg)
+	if err != nil {
+		return false, fmt.Errorf("failed to build router advertisement: %v", err)
+	}
+
+	next := normalizeLifetimes(ra)
+
+	// The first multicast RA is always sent on startup.
+	if a.last == nil || reflect.DeepEqual(a.last, next) {
+		return false, nil
+	}
+
+	a.last = next
+	return true, nil
+}
+
 // deadlineNow causes connection deadlines to trigger immediately.
 var deadlineNow = time.Unix(1, 0)
 
@@ -248,7 +574,7 @@ func (a *Advertiser) listen(ctx context.Context, reqC chan<- request) error {
 	eg.Go(func() error {
 		<-ctx.Done()
 
-		if err := a.c.SetReadDeadline(deadlineNow); err != nil {
+		if err := a.rc.SetReadDeadline(deadlineNow); err != nil {
 			return fmt.Errorf("failed to interrupt listener: %v", err)
 		}
 
@@ -263,7 +589,7 @@ func (a *Advertiser) listen(ctx context.Context, reqC chan<- request) error {
 		default:
 		}
 
-		m, _, host, err := a.c.ReadFrom()
+		b, hopLimit, host, err := a.rc.ReadFrom()
 		if err != nil {
 			if ctx.Err() != nil {
 				// Context canceled.
@@ -282,16 +608,25 @@ func (a *Advertiser) listen(ctx context.Context, reqC chan<- request) error {
 			return fmt.Errorf("failed to read router solicitations: %v", err)
 		}
 
-		a.mm.MessagesReceivedTotal.WithLabelValues(a.cfg.Name, m.Type().String()).Add(1)
+		// Drop invalid solicitations, such as those which may have been
+		// spoofed by an off-link host. Valid solicitations are counted with
+		// an empty reason.
+		rs, reason := checkSolicitation(b, hopLimit, host)
+		typ := ipv6.ICMPTypeRouterSolicitation.String()
+		a.mm.MessagesReceivedTotal.WithLabelValues(a.cfg.Name, typ, reason).Add(1)
+		if reason != "" {
+			continue
+		}
 
-		if _, ok := m.(*ndp.RouterSolicitation); !ok {
-			a.logf("received NDP message of type %T, ignoring", m)
+		// Drop solicitations from hosts which are soliciting too often, or
+		// when all hosts are soliciting too often.
+		if ok, limit := a.rl.Allow(time.Now(), host, sourceLLA(rs)); !ok {
+			a.mm.RouterSolicitationsRateLimitedTotal.WithLabelValues(a.cfg.Name, limit).Inc()
 			continue
 		}
 
-		// Issue a unicast RA.
-		// TODO: consider checking for numerous RS in succession and issuing
-		// a multicast RA in response.
+		// Request a response, which the scheduler may send as a unicast or
+		// multicast RA.
 		reqC <- request{IP: host}
 	}
 }
@@ -302,8 +637,8 @@ func (a *Advertiser) schedule(ctx context.Context, reqC <-chan request) error {
 	var (
 		sg = schedgroup.New(ctx)
 
-		prng          = rand.New(rand.NewSource(time.Now().UnixNano()))
-		lastMulticast time.Time
+		prng = rand.New(rand.NewSource(time.Now().UnixNano()))
+		ms   multicastSchedule
 	)
 
 	for {
@@ -322,30 +657,36 @@ func (a *Advertiser) schedule(ctx context.Context, reqC <-chan request) error {
 		case req = <-reqC:
 		}
 
-		if !req.IP.IsMulticast() {
-			// This is a unicast RA. Delay it for a short period of time per
-			// the RFC and then send it.
-			delay := time.Duration(prng.Int63n(maxRADelay.Nanoseconds())) * time.Nanosecond
+		now := time.Now()
+		if req.IP.IsMulticast() {
+			// Ensure that we space out multicast RAs as required by the RFC.
+			delay := ms.Unsolicited(now, req.Next)
 			sg.Delay(delay, func() error {
 				return a.sendWorker(req.IP)
 			})
 			continue
 		}
 
-		var delay time.Duration
-		if lastMulticast.IsZero() {
-			// We have not sent any multicast RAs; no delay.
-			delay = 0
-		} else {
-			// Ensure that we space out multicast RAs as required by the RFC.
-			delay = time.Since(lastMulticast)
-			if delay < minDelayBetweenRAs {
-				delay = minDelayBetweenRAs
-			}
+		// This is a response to a RS. Delay it for a short random period of
+		// time per the RFC and then send it, unless it can be coalesced into
+		// a multicast RA.
+		res, delay := ms.Solicited(
+			now,
+			time.Duration(prng.Int63n(maxRADelay.Nanoseconds()))*time.Nanosecond,
+			req.IP.Equal(net.IPv6unspecified),
+		)
+
+		switch res {
+		case respondUnicast:
+			// Send to the soliciting host.
+		case respondMulticast:
+			a.mm.RouterSolicitationsCoalescedTotal.WithLabelValues(a.cfg.Name).Inc()
+			req.IP = net.IPv6linklocalallnodes
+		case respondCoalesced:
+			a.mm.RouterSolicitationsCoalescedTotal.WithLabelValues(a.cfg.Name).Inc()
+			continue
 		}
 
-		// Ready to send this multicast RA.
-		lastMulticast = time.Now()
 		sg.Delay(delay, func() error {
 			return a.sendWorker(req.IP)
 		})
@@ -354,11 +695,19 @@ func (a *Advertiser) schedule(ctx context.Context, reqC <-chan request) error {
 
 // sendWorker is a goroutine worker which sends a router advertisemnt to ip.
 func (a *Advertiser) sendWorker(ip net.IP) error {
+	a.stopMu.RLock()
+	defer a.stopMu.RUnlock()
+	if a.stopped {
+		// Shutting down, the final router advertisements have been or will
+		// be sent.
+		return nil
+	}
+
 	busy := a.mm.SchedulerWorkers.WithLabelValues(a.cfg.Name)
 	busy.Inc()
 	defer busy.Dec()
 
-	if err := a.send(ip); err != nil {
+	if err := a.send(ip, false); err != nil {
 		a.logf("failed to send scheduled router advertisement to %s: %v", ip, err)
 		a.mm.ErrorsTotal.WithLabelValues(a.cfg.Name, "transmit").Inc()
 
@@ -377,15 +726,36 @@ func (a *Advertiser) sendWorker(ip net.IP) error {
 }
 
 // send sends a single router advertisement to the destination IP address,
-// which may be a unicast or multicast address.
-func (a *Advertiser) send(dst net.IP) error {
+// which may be a unicast or multicast address. If final is true, the router
+// advertisement is one of the final advertisements sent on shutdown.
+func (a *Advertiser) send(dst net.IP, final bool) error {
 	// Build a router advertisement from configuration and always append
-	// the source address option.
+	// the source address option. Record the contents of multicast RAs so
+	// changes can be detected.
+	a.mu.Lock()
 	ra, err := a.b.Build(a.cfg)
+	if err == nil && dst.IsMulticast() {
+		a.last = normalizeLifetimes(ra)
+	}
+	a.mu.Unlock()
 	if err != nil {
 		return fmt.Errorf("failed to build router advertisement: %v", err)
 	}
 
+	a.mm.DeprecatedPrefixes.WithLabelValues(a.cfg.Name).Set(float64(a.b.Tracker.Deprecated()))
+
+	if final {
+		// This host is no longer a default router.
+		ra.RouterLifetime = 0
+		ra.RouterSelectionPreference = ndp.Medium
+
+		// If configured, indicate that hosts should stop using the prefixes
+		// and other configuration served by this router as it shuts down.
+		if a.cfg.ShutdownDeprecatePrefixes {
+			deprecate(ra, a.cfg.ShutdownValidLifetime)
+		}
+	}
+
 	// TODO: apparently it is also valid to omit this, but we can think
 	// about that later.
 	ra.Options = append(ra.Options, &ndp.LinkLayerAddress{
@@ -396,16 +766,25 @@ func (a *Advertiser) send(dst net.IP) error {
 	// If the interface is not forwarding packets, we must set the router
 	// lifetime field to zero, per:
 	//  https://tools.ietf.org/html/rfc4861#section-6.2.5.
-	forwarding, err := getIPv6Forwarding(a.ifi.Name)
+	if forwarding, _ := a.fc.Get(a.cfg.Name); !forwarding {
+		ra.RouterLifetime = 0
+		ra.RouterSelectionPreference = ndp.Medium
+	}
+
+	// Hosts drop fragmented NDP messages, so split advertisements which
+	// exceed the interface MTU into multiple packets.
+	ras, err := splitRA(ra, a.ifi.MTU)
 	if err != nil {
-		return fmt.Errorf("failed to get IPv6 forwarding state: %v", err)
+		return fmt.Errorf("failed to split router advertisement: %v", err)
 	}
-	if !forwarding {
-		ra.RouterLifetime = 0
+	if len(ras) > 1 {
+		a.mm.RouterAdvertisementSplitsTotal.WithLabelValues(a.cfg.Name).Inc()
 	}
 
-	if err := a.c.WriteTo(ra, nil, dst); err != nil {
-		return fmt.Errorf("failed to send router advertisement to %s: %v", dst, err)
+	for _, ra := range ras {
+		if err := a.c.WriteTo(ra, nil, dst); err != nil {
+			return fmt.Errorf("failed to send router advertisement to %s: %v", dst, err)
+		}
 	}
 
 	return nil
@@ -428,7 +807,7 @@ func multicastDelay(r *rand.Rand, i int, min, max int64) time.Duration {
 		d = (time.Duration(max) * time.Nanosecond).Round(time.Second)
 	} else {
 		// min <= wait <= max, rounded to 1 second granularity.
-		d = (time.Duration(min+rand.Int63n(max-min)) * time.Nanosecond).Round(time.Second)
+		d = (time.Duration(min+r.Int63n(max-min)) * time.Nanosecond).Round(time.Second)
 	}
 
 	// For first few advertisements, select a shorter wait time so routers
@@ -439,3 +818,87 @@ func multicastDelay(r *rand.Rand, i int, min, max int64) time.Duration {
 
 	return d
 }
+
+// Header lengths used to compute the size of a router advertisement packet.
+const (
+	ipv6HeaderLen = 40
+	raHeaderLen   = 16
+)
+
+// splitRA splits ra into multiple router advertisements which each fit
+// within mtu. Each advertisement carries the header of ra and any source
+// link-layer address options, and the remaining options are distributed in
+// order. If ra fits within mtu, it is returned unmodified.
+func splitRA(ra *ndp.RouterAdvertisement, mtu int) ([]*ndp.RouterAdvertisement, error) {
+	b, err := ndp.MarshalMessage(ra)
+	if err != nil {
+		return nil, err
+	}
+	if ipv6HeaderLen+len(b) <= mtu {
+		return []*ndp.RouterAdvertisement{ra}, nil
+	}
+
+	var (
+		common, opts []ndp.Option
+		lens         []int
+		avail        = mtu - ipv6HeaderLen - raHeaderLen
+	)
+
+	for _, o := range ra.Options {
+		l, err := optionLen(o)
+		if err != nil {
+			return nil, err
+		}
+
+		if lla, ok := o.(*ndp.LinkLayerAddress); ok && lla.Direction == ndp.Source {
+			common = append(common, o)
+			avail -= l
+			continue
+		}
+
+		opts = append(opts, o)
+		lens = append(lens, l)
+	}
+
+	var (
+		ras  []*ndp.RouterAdvertisement
+		next []ndp.Option
+		size int
+	)
+
+	for i, o := range opts {
+		if lens[i] > avail {
+			return nil, fmt.Errorf("option %T of %d bytes does not fit within MTU %d", o, lens[i], mtu)
+		}
+
+		if size+lens[i] > avail {
+			ras = append(ras, withOptions(ra, next, common))
+			next, size = nil, 0
+		}
+
+		next = append(next, o)
+		size += lens[i]
+	}
+
+	return append(ras, withOptions(ra, next, common)), nil
+}
+
+// optionLen computes the marshaled length of an NDP option.
+func optionLen(o ndp.Option) (int, error) {
+	b, err := ndp.MarshalMessage(&ndp.RouterAdvertisement{Options: []ndp.Option{o}})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(b) - raHeaderLen, nil
+}
+
+// withOptions returns a copy of ra with options opts followed by common.
+func withOptions(ra *ndp.RouterAdvertisement, opts, common []ndp.Option) *ndp.RouterAdvertisement {
+	out := *ra
+	out.Options = make([]ndp.Option, 0, len(opts)+len(common))
+	out.Options = append(out.Options, opts...)
+	out.Options = append(out.Options, common...)
+
+	return &out
+}
@@ -19,6 +19,7 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"math/rand"
 	"net"
 	"os/exec"
@@ -38,7 +39,9 @@ func TestAdvertiserLinuxUnsolicited(t *testing.T) {
 	// Configure a variety of plugins to ensure that everything is handled
 	// appropriately over the wire.
 	cfg := &config.Interface{
-		OtherConfig: true,
+		OtherConfig:            true,
+		MobileIPv6HomeAgent:    true,
+		NeighborDiscoveryProxy: true,
 		Plugins: []config.Plugin{
 			&config.DNSSL{
 				Lifetime: 10 * time.Second,
@@ -63,6 +66,11 @@ func TestAdvertiserLinuxUnsolicited(t *testing.T) {
 					mustIP("2001:db8::2"),
 				},
 			},
+			&config.Route{
+				Prefix:     mustCIDR("fd00::/48"),
+				Preference: ndp.High,
+				Lifetime:   10 * time.Second,
+			},
 		},
 	}
 
@@ -79,7 +87,9 @@ func TestAdvertiserLinuxUnsolicited(t *testing.T) {
 
 	// Expect a complete RA.
 	want := &ndp.RouterAdvertisement{
-		OtherConfiguration: true,
+		OtherConfiguration:     true,
+		MobileIPv6HomeAgent:    true,
+		NeighborDiscoveryProxy: true,
 		Options: []ndp.Option{
 			&ndp.DNSSearchList{
 				Lifetime: 10 * time.Second,
@@ -103,6 +113,17 @@ func TestAdvertiserLinuxUnsolicited(t *testing.T) {
 					mustIP("2001:db8::2"),
 				},
 			},
+			// Package ndp does not support route information options, so
+			// they are parsed as raw options.
+			&ndp.RawOption{
+				Type:   optRouteInformation,
+				Length: 2,
+				Value: []byte{
+					48, 0x08,
+					0x00, 0x00, 0x00, 0x0a,
+					0xfd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				},
+			},
 			&ndp.LinkLayerAddress{
 				Direction: ndp.Source,
 				Addr:      ad.ifi.HardwareAddr,
@@ -120,6 +141,7 @@ func TestAdvertiserLinuxUnsolicitedShutdown(t *testing.T) {
 	const lifetime = 3 * time.Second
 	cfg := &config.Interface{
 		DefaultLifetime: lifetime,
+		Preference:      ndp.High,
 	}
 
 	var got []ndp.Message
@@ -143,16 +165,157 @@ func TestAdvertiserLinuxUnsolicitedShutdown(t *testing.T) {
 		Addr:      ad.ifi.HardwareAddr,
 	}}
 
-	// Expect only the first message to contain a RouterLifetime field as it
-	// should be cleared on shutdown.
+	// Expect only the first message to contain a RouterLifetime field and
+	// non-default preference as they should be cleared on shutdown.
 	want := []ndp.Message{
 		&ndp.RouterAdvertisement{
-			RouterLifetime: lifetime,
-			Options:        options,
+			RouterSelectionPreference: ndp.High,
+			RouterLifetime:            lifetime,
+			Options:                   options,
 		},
 		&ndp.RouterAdvertisement{
-			RouterLifetime: 0,
-			Options:        options,
+			RouterSelectionPreference: ndp.Medium,
+			RouterLifetime:            0,
+			Options:                   options,
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected router advertisements (-want +got):\n%s", diff)
+	}
+}
+
+func TestAdvertiserLinuxUnsolicitedShutdownFinal(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+
+	// The shutdown timeout only leaves enough time for two of the three
+	// final advertisements.
+	cfg := &config.Interface{
+		DefaultLifetime: 3 * time.Second,
+		ShutdownTimeout: 4 * time.Second,
+	}
+
+	var lifetimes []time.Duration
+	_, done := testAdvertiserClient(t, cfg, func(cancel func(), cctx *clientContext) {
+		// Read the RA the advertiser sends on startup, then stop it and capture
+		// the ones it sends on shutdown.
+		for i := 0; i < 3; i++ {
+			m, _, _, err := cctx.c.ReadFrom()
+			if err != nil {
+				t.Fatalf("failed to read RA: %v", err)
+			}
+
+			lifetimes = append(lifetimes, m.(*ndp.RouterAdvertisement).RouterLifetime)
+			cancel()
+		}
+
+		// No more advertisements should arrive after the shutdown timeout.
+		if err := cctx.c.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+			t.Fatalf("failed to set read deadline: %v", err)
+		}
+
+		if _, _, _, err := cctx.c.ReadFrom(); err == nil {
+			t.Fatal("expected no more router advertisements, but one arrived")
+		}
+	})
+	defer done()
+
+	want := []time.Duration{3 * time.Second, 0, 0}
+	if diff := cmp.Diff(want, lifetimes); diff != "" {
+		t.Fatalf("unexpected router lifetimes (-want +got):\n%s", diff)
+	}
+}
+
+func TestAdvertiserLinuxUnsolicitedShutdownDeprecatePrefixes(t *testing.T) {
+	// The advertiser will deprecate its prefixes and expire its other
+	// configuration when it shuts down.
+	cfg := &config.Interface{
+		ShutdownDeprecatePrefixes: true,
+		ShutdownValidLifetime:     15 * time.Second,
+		Plugins: []config.Plugin{
+			&config.DNSSL{
+				Lifetime:    10 * time.Second,
+				DomainNames: []string{"foo.example.com"},
+			},
+			&config.Prefix{
+				Prefix:            mustCIDR("2001:db8::/32"),
+				OnLink:            true,
+				PreferredLifetime: 10 * time.Second,
+				ValidLifetime:     20 * time.Second,
+			},
+			&config.RDNSS{
+				Lifetime: 10 * time.Second,
+				Servers:  []net.IP{mustIP("2001:db8::1")},
+			},
+			&config.Route{
+				Prefix:     mustCIDR("fd00::/48"),
+				Preference: ndp.High,
+				Lifetime:   10 * time.Second,
+			},
+		},
+	}
+
+	var got []ndp.Message
+	ad, done := testAdvertiserClient(t, cfg, func(cancel func(), cctx *clientContext) {
+		// Read the RA the advertiser sends on startup, then stop it and capture the
+		// one it sends on shutdown.
+		for i := 0; i < 2; i++ {
+			m, _, _, err := cctx.c.ReadFrom()
+			if err != nil {
+				t.Fatalf("failed to read RA: %v", err)
+			}
+
+			got = append(got, m)
+			cancel()
+		}
+	})
+	defer done()
+
+	options := func(preferred, valid, lifetime time.Duration) []ndp.Option {
+		return []ndp.Option{
+			&ndp.DNSSearchList{
+				Lifetime:    lifetime,
+				DomainNames: []string{"foo.example.com"},
+			},
+			&ndp.PrefixInformation{
+				PrefixLength:      32,
+				OnLink:            true,
+				PreferredLifetime: preferred,
+				ValidLifetime:     valid,
+				Prefix:            mustIP("2001:db8::"),
+			},
+			&ndp.RecursiveDNSServer{
+				Lifetime: lifetime,
+				Servers:  []net.IP{mustIP("2001:db8::1")},
+			},
+			&ndp.RawOption{
+				Type:   optRouteInformation,
+				Length: 2,
+				Value: []byte{
+					48, 0x08,
+					0x00, 0x00, 0x00, uint8(lifetime.Seconds()),
+					0xfd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				},
+			},
+			&ndp.LinkLayerAddress{
+				Direction: ndp.Source,
+				Addr:      ad.ifi.HardwareAddr,
+			},
+		}
+	}
+
+	// Expect the second message to deprecate the prefix, shorten its valid
+	// lifetime, and expire all other configuration.
+	want := []ndp.Message{
+		&ndp.RouterAdvertisement{
+			RouterSelectionPreference: ndp.Medium,
+			Options:                   options(10*time.Second, 20*time.Second, 10*time.Second),
+		},
+		&ndp.RouterAdvertisement{
+			RouterSelectionPreference: ndp.Medium,
+			Options:                   options(0, 15*time.Second, 0),
 		},
 	}
 
@@ -161,6 +324,123 @@ func TestAdvertiserLinuxUnsolicitedShutdown(t *testing.T) {
 	}
 }
 
+func TestAdvertiserLinuxUnsolicitedAddressChange(t *testing.T) {
+	// Serve a prefix for each address on the interface.
+	cfg := &config.Interface{
+		Plugins: []config.Plugin{
+			&config.Prefix{
+				Prefix:            mustCIDR("::/64"),
+				OnLink:            true,
+				PreferredLifetime: 10 * time.Second,
+				ValidLifetime:     20 * time.Second,
+			},
+		},
+	}
+
+	ad, c, _, done := testAdvertiser(t, cfg)
+	defer done()
+
+	// Use long intervals so that only a detected change could cause a
+	// second RA to be sent before the read deadline.
+	ad.cfg.MinInterval = 30 * time.Second
+	ad.cfg.MaxInterval = 60 * time.Second
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var eg errgroup.Group
+	eg.Go(func() error {
+		return ad.Advertise(ctx)
+	})
+
+	read := func() *ndp.RouterAdvertisement {
+		if err := c.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+			t.Fatalf("failed to set client read deadline: %v", err)
+		}
+
+		m, _, _, err := c.ReadFrom()
+		if err != nil {
+			t.Fatalf("failed to read RA: %v", err)
+		}
+
+		return m.(*ndp.RouterAdvertisement)
+	}
+
+	prefixes := func(ra *ndp.RouterAdvertisement) []net.IP {
+		var ips []net.IP
+		for _, o := range ra.Options {
+			if pi, ok := o.(*ndp.PrefixInformation); ok {
+				ips = append(ips, pi.Prefix)
+			}
+		}
+
+		return ips
+	}
+
+	// The interface has no global addresses, so no prefixes are served
+	// until one is added.
+	if diff := cmp.Diff([]net.IP(nil), prefixes(read())); diff != "" {
+		t.Fatalf("unexpected initial prefixes (-want +got):\n%s", diff)
+	}
+
+	shell(t, "ip", "addr", "add", "2001:db8::1/64", "dev", ad.ifi.Name, "nodad")
+
+	if diff := cmp.Diff([]net.IP{mustIP("2001:db8::")}, prefixes(read())); diff != "" {
+		t.Fatalf("unexpected prefixes after address change (-want +got):\n%s", diff)
+	}
+
+	cancel()
+	if err := eg.Wait(); err != nil {
+		t.Fatalf("failed to stop advertiser: %v", err)
+	}
+}
+
+func TestAdvertiserLinuxConcurrentBuild(t *testing.T) {
+	// Both plugins are modified while building RAs: the DNSSL lifetime is
+	// resolved and the ::/64 prefixes are tracked.
+	cfg := &config.Interface{
+		Plugins: []config.Plugin{
+			&config.DNSSL{
+				Lifetime:    config.DurationAuto,
+				DomainNames: []string{"example.com"},
+			},
+			&config.Prefix{
+				Prefix:            mustCIDR("::/64"),
+				OnLink:            true,
+				PreferredLifetime: 10 * time.Second,
+				ValidLifetime:     20 * time.Second,
+			},
+		},
+	}
+
+	ad, _, _, done := testAdvertiser(t, cfg)
+	defer done()
+	defer ad.c.Close()
+	defer ad.rc.Close()
+
+	// The change detector and scheduler workers build RAs concurrently, which
+	// the race detector checks.
+	var eg errgroup.Group
+	for i := 0; i < 4; i++ {
+		eg.Go(func() error {
+			for j := 0; j < 10; j++ {
+				if _, err := ad.changed(); err != nil {
+					return err
+				}
+				if err := ad.send(net.IPv6linklocalallnodes, false); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		t.Fatalf("failed to build RAs: %v", err)
+	}
+}
+
 func TestAdvertiserLinuxUnsolicitedDelayed(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping in short mode")
@@ -297,6 +577,44 @@ func TestAdvertiserLinuxIPv6Autoconfiguration(t *testing.T) {
 	}
 }
 
+func TestAdvertiserLinuxDialErrorRestoresAutoconfiguration(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("skipping, advertiser tests only run on Linux")
+	}
+
+	skipUnprivileged(t)
+
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		veth0 = fmt.Sprintf("cradveth%d", r.Intn(65535))
+		veth1 = fmt.Sprintf("cradveth%d", r.Intn(65535))
+	)
+
+	// Without a link-local address, the NDP listener cannot be created after
+	// IPv6 autoconfiguration is disabled.
+	shell(t, "ip", "link", "add", veth0, "type", "veth", "peer", "name", veth1)
+	defer shell(t, "ip", "link", "del", veth0)
+	shell(t, "ip", "link", "set", "dev", veth0, "addrgenmode", "none")
+	shell(t, "ip", "link", "set", "up", veth0)
+	shell(t, "ip", "link", "set", "up", veth1)
+
+	// Creating the advertiser repeatedly, as the supervisor would, must not
+	// leave IPv6 autoconfiguration disabled.
+	for i := 0; i < 2; i++ {
+		if _, err := NewAdvertiser(config.Interface{Name: veth0}, nil, nil); err == nil {
+			t.Fatal("expected an error creating the advertiser, but none occurred")
+		}
+
+		auto, err := getIPv6Autoconf(veth0)
+		if err != nil {
+			t.Fatalf("failed to get IPv6 autoconfiguration state: %v", err)
+		}
+		if !auto {
+			t.Fatalf("attempt %d: IPv6 autoconfiguration was not restored", i)
+		}
+	}
+}
+
 func TestAdvertiserLinuxIPv6Forwarding(t *testing.T) {
 	const lifetime = 3 * time.Second
 	cfg := &config.Interface{
@@ -353,6 +671,398 @@ func TestAdvertiserLinuxIPv6Forwarding(t *testing.T) {
 	}
 }
 
+func TestAdvertiserLinuxIPv6ForwardingChange(t *testing.T) {
+	const lifetime = 3 * time.Second
+	cfg := &config.Interface{
+		DefaultLifetime: lifetime,
+	}
+
+	ad, c, _, done := testAdvertiser(t, cfg)
+	defer done()
+
+	// Use long intervals so that only a forwarding change could cause a
+	// second RA to be sent before the read deadline.
+	ad.cfg.MinInterval = 30 * time.Second
+	ad.cfg.MaxInterval = 60 * time.Second
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var eg errgroup.Group
+	eg.Go(func() error {
+		return ad.Advertise(ctx)
+	})
+
+	var got []ndp.Message
+	for i := 0; i < 2; i++ {
+		if err := c.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+			t.Fatalf("failed to set client read deadline: %v", err)
+		}
+
+		m, _, _, err := c.ReadFrom()
+		if err != nil {
+			t.Fatalf("failed to read RA: %v", err)
+		}
+		got = append(got, m)
+
+		// Forwarding is disabled after the first RA arrives, which must
+		// trigger another RA without a router solicitation.
+		if i == 0 {
+			if err := setIPv6Forwarding(ad.ifi.Name, false); err != nil {
+				t.Fatalf("failed to disable IPv6 forwarding: %v", err)
+			}
+		}
+	}
+
+	if fwd, ok := ad.fc.Get(ad.ifi.Name); !ok || fwd {
+		t.Fatalf("unexpected cached forwarding state: %t, known: %t", fwd, ok)
+	}
+
+	cancel()
+	if err := eg.Wait(); err != nil {
+		t.Fatalf("failed to stop advertiser: %v", err)
+	}
+
+	options := []ndp.Option{&ndp.LinkLayerAddress{
+		Direction: ndp.Source,
+		Addr:      ad.ifi.HardwareAddr,
+	}}
+
+	want := []ndp.Message{
+		&ndp.RouterAdvertisement{
+			RouterLifetime: lifetime,
+			Options:        options,
+		},
+		&ndp.RouterAdvertisement{
+			RouterLifetime: 0,
+			Options:        options,
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected router advertisements (-want +got):\n%s", diff)
+	}
+}
+
+func TestAdvertiserLinuxSysctls(t *testing.T) {
+	var (
+		acceptRA       = 2
+		acceptRADefrtr = false
+		mtu            = 1400
+	)
+
+	cfg := &config.Interface{
+		Sysctls: config.Sysctls{
+			AcceptRA:       &acceptRA,
+			AcceptRADefrtr: &acceptRADefrtr,
+			MTU:            &mtu,
+		},
+	}
+
+	ad, _, _, done := testAdvertiser(t, cfg)
+	defer done()
+
+	sysctls := func() []managedSysctl {
+		var ss []managedSysctl
+		for _, key := range []string{sysctlAcceptRA, sysctlAcceptRADefrtr, sysctlMTU} {
+			v, err := getSysctl(ad.ifi.Name, key)
+			if err != nil {
+				t.Fatalf("failed to get sysctl %s: %v", key, err)
+			}
+
+			ss = append(ss, managedSysctl{Key: key, Value: v})
+		}
+
+		return ss
+	}
+
+	// The sysctls are set when the advertiser is created.
+	want := []managedSysctl{
+		{Key: sysctlAcceptRA, Value: 2},
+		{Key: sysctlAcceptRADefrtr, Value: 0},
+		{Key: sysctlMTU, Value: 1400},
+	}
+	if diff := cmp.Diff(want, sysctls()); diff != "" {
+		t.Fatalf("unexpected sysctls after startup (-want +got):\n%s", diff)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ad.Advertise(ctx); err != nil {
+		t.Fatalf("failed to advertise: %v", err)
+	}
+
+	// The kernel defaults for a new interface are restored on shutdown.
+	want = []managedSysctl{
+		{Key: sysctlAcceptRA, Value: 1},
+		{Key: sysctlAcceptRADefrtr, Value: 1},
+		{Key: sysctlMTU, Value: 1500},
+	}
+	if diff := cmp.Diff(want, sysctls()); diff != "" {
+		t.Fatalf("unexpected sysctls after shutdown (-want +got):\n%s", diff)
+	}
+}
+
+func TestAdvertiserLinuxSysctlsRestoredOnError(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("skipping, advertiser tests only run on Linux")
+	}
+
+	skipUnprivileged(t)
+
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		veth0 = fmt.Sprintf("cradveth%d", r.Intn(65535))
+		veth1 = fmt.Sprintf("cradveth%d", r.Intn(65535))
+	)
+
+	// Without a link-local address, the NDP listener cannot be created after
+	// the sysctls are applied.
+	shell(t, "ip", "link", "add", veth0, "type", "veth", "peer", "name", veth1)
+	defer shell(t, "ip", "link", "del", veth0)
+	shell(t, "ip", "link", "set", "dev", veth0, "addrgenmode", "none")
+	shell(t, "ip", "link", "set", "up", veth0)
+	shell(t, "ip", "link", "set", "up", veth1)
+
+	var (
+		acceptRA = 2
+		badMTU   = 100
+	)
+
+	tests := []struct {
+		name    string
+		sysctls config.Sysctls
+	}{
+		{
+			// The MTU is below the IPv6 minimum, so the kernel rejects it
+			// after accept_ra is set.
+			name: "set sysctls",
+			sysctls: config.Sysctls{
+				AcceptRA: &acceptRA,
+				MTU:      &badMTU,
+			},
+		},
+		{
+			name: "dial",
+			sysctls: config.Sysctls{
+				AcceptRA: &acceptRA,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := NewAdvertiser(config.Interface{
+			Name:    veth0,
+			Sysctls: tt.sysctls,
+		}, nil, nil)
+		if err == nil {
+			t.Fatalf("%s: expected an error creating the advertiser, but none occurred", tt.name)
+		}
+
+		// The kernel defaults for a new interface must be restored.
+		for _, want := range []managedSysctl{
+			{Key: sysctlAcceptRA, Value: 1},
+			{Key: sysctlMTU, Value: 1500},
+		} {
+			v, err := getSysctl(veth0, want.Key)
+			if err != nil {
+				t.Fatalf("%s: failed to get sysctl %s: %v", tt.name, want.Key, err)
+			}
+			if v != want.Value {
+				t.Fatalf("%s: sysctl %s was not restored: %d", tt.name, want.Key, v)
+			}
+		}
+	}
+}
+
+func TestAdvertiserLinuxListenerErrorRestoresState(t *testing.T) {
+	acceptRA := 2
+	cfg := &config.Interface{
+		Sysctls: config.Sysctls{AcceptRA: &acceptRA},
+	}
+
+	ad, _, _, done := testAdvertiser(t, cfg)
+	defer done()
+
+	// Force the router solicitation listener to fail while the supervisor
+	// is running the advertiser.
+	if err := ad.rc.Close(); err != nil {
+		t.Fatalf("failed to close router solicitation listener: %v", err)
+	}
+
+	if err := runUntilGone(context.Background(), ad, ad.ifi, nil); err == nil {
+		t.Fatal("expected an error running the advertiser, but none occurred")
+	}
+
+	// The interface must be restored to its original state, so the next
+	// advertiser records the original state as well.
+	check := func(name string) {
+		auto, err := getIPv6Autoconf(ad.ifi.Name)
+		if err != nil {
+			t.Fatalf("%s: failed to get IPv6 autoconfiguration: %v", name, err)
+		}
+		if !auto {
+			t.Fatalf("%s: IPv6 autoconfiguration was not restored", name)
+		}
+
+		v, err := getSysctl(ad.ifi.Name, sysctlAcceptRA)
+		if err != nil {
+			t.Fatalf("%s: failed to get sysctl %s: %v", name, sysctlAcceptRA, err)
+		}
+		if v != 1 {
+			t.Fatalf("%s: sysctl %s was not restored: %d", name, sysctlAcceptRA, v)
+		}
+	}
+
+	check("listener error")
+
+	next, err := NewAdvertiser(ad.cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create next advertiser: %v", err)
+	}
+
+	if !next.autoPrev {
+		t.Fatal("next advertiser recorded IPv6 autoconfiguration as disabled")
+	}
+
+	want := []managedSysctl{{Key: sysctlAcceptRA, Value: 1}}
+	if diff := cmp.Diff(want, next.sysctlPrev); diff != "" {
+		t.Fatalf("unexpected previous sysctls for next advertiser (-want +got):\n%s", diff)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := next.Advertise(ctx); err != nil {
+		t.Fatalf("failed to advertise: %v", err)
+	}
+
+	check("shutdown")
+}
+
+func TestAdvertiserLinuxWaitForInterface(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("skipping, advertiser tests only run on Linux")
+	}
+
+	skipUnprivileged(t)
+
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		veth0 = fmt.Sprintf("cradveth%d", r.Intn(65535))
+		veth1 = fmt.Sprintf("cradveth%d", r.Intn(65535))
+	)
+
+	cfg := config.Interface{
+		Name:             veth0,
+		WaitForInterface: true,
+		MinInterval:      1 * time.Second,
+		MaxInterval:      1 * time.Second,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The interface does not exist yet, so the supervisor must wait for it.
+	var eg errgroup.Group
+	eg.Go(func() error {
+		return superviseAdvertiser(ctx, cfg, log.New(ioutil.Discard, "", 0), NewAdvertiserMetrics(nil), newForwardingCache())
+	})
+
+	shell(t, "ip", "link", "add", veth0, "type", "veth", "peer", "name", veth1)
+	mustSysctl(t, veth0, "accept_dad", "0")
+	mustSysctl(t, veth1, "accept_dad", "0")
+	mustSysctl(t, veth0, "forwarding", "1")
+	shell(t, "ip", "link", "set", "up", veth0)
+	shell(t, "ip", "link", "set", "up", veth1)
+
+	waitInterfacesReady(t, veth0, veth1)
+
+	ifi, err := net.InterfaceByName(veth1)
+	if err != nil {
+		t.Fatalf("failed to look up second veth: %v", err)
+	}
+
+	c, _, err := ndp.Dial(ifi, ndp.LinkLocal)
+	if err != nil {
+		t.Fatalf("failed to create NDP client connection: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatalf("failed to set client read deadline: %v", err)
+	}
+
+	// Expect the advertiser to start once the interface is ready.
+	for {
+		m, _, _, err := c.ReadFrom()
+		if err != nil {
+			t.Fatalf("failed to read RA: %v", err)
+		}
+		if _, ok := m.(*ndp.RouterAdvertisement); ok {
+			break
+		}
+	}
+
+	// Removing the interface must not stop the supervisor.
+	shell(t, "ip", "link", "del", veth0)
+	time.Sleep(500 * time.Millisecond)
+
+	cancel()
+	if err := eg.Wait(); err != nil {
+		t.Fatalf("failed to supervise advertiser: %v", err)
+	}
+}
+
+func TestAdvertiserLinuxWaitLinkLocal(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("skipping, advertiser tests only run on Linux")
+	}
+
+	skipUnprivileged(t)
+
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		veth0 = fmt.Sprintf("cradveth%d", r.Intn(65535))
+		veth1 = fmt.Sprintf("cradveth%d", r.Intn(65535))
+	)
+
+	// Leave duplicate address detection enabled so the link-local address
+	// is tentative immediately after the interface comes up.
+	shell(t, "ip", "link", "add", veth0, "type", "veth", "peer", "name", veth1)
+	defer shell(t, "ip", "link", "del", veth0)
+	shell(t, "ip", "link", "set", "up", veth0)
+	shell(t, "ip", "link", "set", "up", veth1)
+
+	ad, err := NewAdvertiser(config.Interface{
+		Name:             veth0,
+		LinkLocalTimeout: 10 * time.Second,
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create advertiser: %v", err)
+	}
+	defer ad.c.Close()
+	defer ad.rc.Close()
+
+	lls, err := linkLocalAddrs(ad.ifi)
+	if err != nil {
+		t.Fatalf("failed to fetch link-local addresses: %v", err)
+	}
+
+	for _, ll := range lls {
+		if !ll.IP.Equal(ad.ip) {
+			continue
+		}
+
+		if ll.Tentative || ll.DADFailed {
+			t.Fatalf("advertiser bound to unusable address: %+v", ll)
+		}
+
+		return
+	}
+
+	t.Fatalf("advertiser address %s not found on interface", ad.ip)
+}
+
 func testAdvertiser(t *testing.T, cfg *config.Interface) (*Advertiser, *ndp.Conn, net.HardwareAddr, func()) {
 	t.Helper()
 
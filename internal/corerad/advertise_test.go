@@ -15,10 +15,12 @@ package corerad
 
 import (
 	"math/rand"
+	"net"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/ndp"
 )
 
 func Test_multicastDelay(t *testing.T) {
@@ -40,7 +42,7 @@ func Test_multicastDelay(t *testing.T) {
 			name:  "random",
 			min:   1 * time.Second,
 			max:   10 * time.Second,
-			delay: 4 * time.Second,
+			delay: 5 * time.Second,
 		},
 		{
 			name: "clamped",
@@ -56,7 +58,7 @@ func Test_multicastDelay(t *testing.T) {
 			i:     100,
 			min:   30 * time.Second,
 			max:   60 * time.Second,
-			delay: 52 * time.Second,
+			delay: 54 * time.Second,
 		},
 	}
 
@@ -69,3 +71,255 @@ func Test_multicastDelay(t *testing.T) {
 		})
 	}
 }
+
+func Test_splitRA(t *testing.T) {
+	lla := &ndp.LinkLayerAddress{
+		Direction: ndp.Source,
+		Addr:      net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+	}
+
+	// Each prefix option is 32 bytes.
+	pi := func(ip string) *ndp.PrefixInformation {
+		return &ndp.PrefixInformation{
+			PrefixLength:      64,
+			OnLink:            true,
+			PreferredLifetime: 10 * time.Second,
+			ValidLifetime:     20 * time.Second,
+			Prefix:            mustIP(ip),
+		}
+	}
+
+	ra := func(opts ...ndp.Option) *ndp.RouterAdvertisement {
+		return &ndp.RouterAdvertisement{
+			CurrentHopLimit:           64,
+			RouterSelectionPreference: ndp.High,
+			RouterLifetime:            30 * time.Minute,
+			Options:                   opts,
+		}
+	}
+
+	// Room for headers, the source link-layer address, and two prefixes.
+	const mtu = ipv6HeaderLen + raHeaderLen + 8 + 2*32
+
+	tests := []struct {
+		name string
+		ra   *ndp.RouterAdvertisement
+		mtu  int
+		ras  []*ndp.RouterAdvertisement
+		ok   bool
+	}{
+		{
+			name: "option too large",
+			ra: ra(&ndp.RecursiveDNSServer{
+				Lifetime: 10 * time.Second,
+				Servers: []net.IP{
+					mustIP("2001:db8::1"), mustIP("2001:db8::2"),
+					mustIP("2001:db8::3"), mustIP("2001:db8::4"),
+				},
+			}, lla),
+			mtu: mtu,
+		},
+		{
+			name: "fits",
+			ra:   ra(pi("2001:db8::"), lla),
+			mtu:  1500,
+			ras:  []*ndp.RouterAdvertisement{ra(pi("2001:db8::"), lla)},
+			ok:   true,
+		},
+		{
+			name: "fits exactly",
+			ra:   ra(pi("2001:db8::"), pi("2001:db8:1::"), lla),
+			mtu:  mtu,
+			ras:  []*ndp.RouterAdvertisement{ra(pi("2001:db8::"), pi("2001:db8:1::"), lla)},
+			ok:   true,
+		},
+		{
+			name: "split",
+			ra: ra(
+				pi("2001:db8::"),
+				pi("2001:db8:1::"),
+				pi("2001:db8:2::"),
+				pi("2001:db8:3::"),
+				lla,
+				pi("2001:db8:4::"),
+			),
+			mtu: mtu,
+			ras: []*ndp.RouterAdvertisement{
+				ra(pi("2001:db8::"), pi("2001:db8:1::"), lla),
+				ra(pi("2001:db8:2::"), pi("2001:db8:3::"), lla),
+				ra(pi("2001:db8:4::"), lla),
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ras, err := splitRA(tt.ra, tt.mtu)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to split: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+			if err != nil {
+				t.Logf("err: %v", err)
+				return
+			}
+
+			if diff := cmp.Diff(tt.ras, ras); diff != "" {
+				t.Fatalf("unexpected router advertisements (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_normalizeLifetimes(t *testing.T) {
+	ra := func(preferred, valid, route time.Duration) *ndp.RouterAdvertisement {
+		return &ndp.RouterAdvertisement{
+			RouterLifetime: 30 * time.Minute,
+			Options: []ndp.Option{
+				&ndp.PrefixInformation{
+					PrefixLength:      64,
+					PreferredLifetime: preferred,
+					ValidLifetime:     valid,
+					Prefix:            mustIP("2001:db8::"),
+				},
+				&routeInformationOption{&ndp.RawOption{
+					Type:   optRouteInformation,
+					Length: 2,
+					Value: []byte{
+						48, 0x08,
+						0x00, 0x00, 0x00, uint8(route.Seconds()),
+						0xfd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+					},
+				}},
+				// Raw options configured by the user are compared as-is,
+				// even when their types match options which carry lifetimes.
+				&ndp.RawOption{
+					Type:   optPrefixInformation,
+					Length: 1,
+					Value:  []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+				},
+				&ndp.RawOption{
+					Type:   optRouteInformation,
+					Length: 1,
+					Value:  []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+				},
+				ndp.NewMTU(1500),
+			},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		a, b  *ndp.RouterAdvertisement
+		equal bool
+	}{
+		{
+			name:  "decremented",
+			a:     ra(20*time.Second, 30*time.Second, 10*time.Second),
+			b:     ra(15*time.Second, 25*time.Second, 5*time.Second),
+			equal: true,
+		},
+		{
+			name: "deprecated",
+			a:    ra(20*time.Second, 30*time.Second, 10*time.Second),
+			b:    ra(0, 30*time.Second, 10*time.Second),
+		},
+		{
+			name: "route expired",
+			a:    ra(20*time.Second, 30*time.Second, 10*time.Second),
+			b:    ra(20*time.Second, 30*time.Second, 0),
+		},
+		{
+			name: "raw option changed",
+			a:    ra(20*time.Second, 30*time.Second, 10*time.Second),
+			b: func() *ndp.RouterAdvertisement {
+				ra := ra(20*time.Second, 30*time.Second, 10*time.Second)
+				ra.Options[2].(*ndp.RawOption).Value[5] = 0x02
+				return ra
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				before = ra(20*time.Second, 30*time.Second, 10*time.Second)
+				a      = normalizeLifetimes(tt.a)
+				b      = normalizeLifetimes(tt.b)
+			)
+
+			if diff := cmp.Diff(before, tt.a); diff != "" {
+				t.Fatalf("input router advertisement was modified (-want +got):\n%s", diff)
+			}
+
+			if equal := cmp.Equal(a, b); equal != tt.equal {
+				t.Fatalf("unexpected normalized equality: %v, diff:\n%s", equal, cmp.Diff(a, b))
+			}
+		})
+	}
+}
+
+func Test_deprecate(t *testing.T) {
+	pi := &ndp.PrefixInformation{
+		PrefixLength:      64,
+		PreferredLifetime: 10 * time.Minute,
+		ValidLifetime:     20 * time.Minute,
+		Prefix:            mustIP("2001:db8::"),
+	}
+
+	ra := func(preferred, valid, route time.Duration) *ndp.RouterAdvertisement {
+		pi := *pi
+		pi.PreferredLifetime, pi.ValidLifetime = preferred, valid
+
+		rap, err := routerAddressPrefix(&pi, mustIP("2001:db8::1"))
+		if err != nil {
+			t.Fatalf("failed to build router address prefix: %v", err)
+		}
+
+		ri, err := routeInformation(mustIP("fd00::"), 48, ndp.Medium, route)
+		if err != nil {
+			t.Fatalf("failed to build route information: %v", err)
+		}
+
+		return &ndp.RouterAdvertisement{
+			Options: []ndp.Option{
+				&pi,
+				rap,
+				ri,
+				// Raw options configured by the user are never modified, even
+				// when their types match options which carry lifetimes.
+				&ndp.RawOption{
+					Type:   optPrefixInformation,
+					Length: 1,
+					Value:  []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+				},
+				&ndp.RawOption{
+					Type:   optRouteInformation,
+					Length: 2,
+					Value: []byte{
+						48, 0x08,
+						0xff, 0xff, 0xff, 0xff,
+						0xfd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+					},
+				},
+				&ndp.RecursiveDNSServer{
+					Lifetime: 10 * time.Minute,
+					Servers:  []net.IP{mustIP("2001:db8::53")},
+				},
+			},
+		}
+	}
+
+	got := ra(10*time.Minute, 20*time.Minute, 30*time.Minute)
+	deprecate(got, 5*time.Minute)
+
+	want := ra(0, 5*time.Minute, 0)
+	want.Options[5].(*ndp.RecursiveDNSServer).Lifetime = 0
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected deprecated router advertisement (-want +got):\n%s", diff)
+	}
+}
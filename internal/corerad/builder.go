@@ -16,6 +16,9 @@ package corerad
 import (
 	"fmt"
 	"net"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/mdlayher/corerad/internal/config"
 	"github.com/mdlayher/ndp"
@@ -25,33 +28,135 @@ import (
 type builder struct {
 	// Addrs is a swappable function which produces IP addresses for an interface.
 	Addrs func() ([]net.Addr, error)
+
+	// ResolvConf is a swappable function which produces the contents of a
+	// resolv.conf file, and reports whether they changed since the last call.
+	ResolvConf func(path string) (*resolvConf, bool, error)
+
+	// DelegatedPrefix is a swappable function which produces the contents of
+	// a delegated prefix file, and reports whether they changed since the last
+	// call.
+	DelegatedPrefix func(path string) (*delegatedPrefix, bool, error)
+
+	// Logf is an optional function which logs events while building router
+	// advertisements.
+	Logf func(format string, v ...interface{})
+
+	// Now is a swappable function which produces the current time. If nil,
+	// time.Now is used.
+	Now func() time.Time
+
+	// Anchor is the time from which lifetimes configured to decrement in
+	// real time are counted down.
+	Anchor time.Time
+
+	// Tracker remembers the prefixes expanded from ::/N so they can be
+	// deprecated when they disappear. If nil, prefixes are not tracked.
+	Tracker *prefixTracker
 }
 
 // Build creates a router advertisement from configuration.
 func (b *builder) Build(ifi config.Interface) (*ndp.RouterAdvertisement, error) {
 	ra := &ndp.RouterAdvertisement{
-		CurrentHopLimit:      ifi.HopLimit,
-		ManagedConfiguration: ifi.Managed,
-		OtherConfiguration:   ifi.OtherConfig,
-		RouterLifetime:       ifi.DefaultLifetime,
-		ReachableTime:        ifi.ReachableTime,
-		RetransmitTimer:      ifi.RetransmitTimer,
+		CurrentHopLimit:           ifi.HopLimit,
+		ManagedConfiguration:      ifi.Managed,
+		OtherConfiguration:        ifi.OtherConfig,
+		MobileIPv6HomeAgent:       ifi.MobileIPv6HomeAgent,
+		RouterSelectionPreference: ifi.Preference,
+		NeighborDiscoveryProxy:    ifi.NeighborDiscoveryProxy,
+		RouterLifetime:            ifi.DefaultLifetime,
+		ReachableTime:             ifi.ReachableTime,
+		RetransmitTimer:           ifi.RetransmitTimer,
+	}
+
+	// A router which is not a default router must use medium preference, per:
+	//  https://tools.ietf.org/html/rfc4191#section-2.2.
+	if ra.RouterLifetime == 0 {
+		ra.RouterSelectionPreference = ndp.Medium
 	}
 
 	for _, p := range ifi.Plugins {
 		switch p := p.(type) {
+		case *config.CaptivePortal:
+			opt, err := captivePortal(p.URI)
+			if err != nil {
+				return nil, err
+			}
+
+			ra.Options = append(ra.Options, opt)
+		case *config.DNR:
+			// If auto, compute lifetime as recommended by the RFC.
+			if p.Lifetime == config.DurationAuto {
+				p.Lifetime = 3 * ifi.MaxInterval
+			}
+
+			opt, err := dnr(p)
+			if err != nil {
+				return nil, err
+			}
+
+			ra.Options = append(ra.Options, opt)
 		case *config.DNSSL:
 			// If auto, compute lifetime as recommended by the RFC.
 			if p.Lifetime == config.DurationAuto {
 				p.Lifetime = 3 * ifi.MaxInterval
 			}
 
+			names := p.DomainNames
+			if p.ResolvConf != "" {
+				names = b.resolvConf(p.ResolvConf).Search
+			}
+
+			// An automatic configuration may not have found any values yet.
+			if len(names) == 0 {
+				break
+			}
+
+			lifetime := p.Lifetime
+			if p.DecrementLifetimes {
+				if lifetime = b.decrement(lifetime); lifetime == 0 {
+					break
+				}
+			}
+
 			ra.Options = append(ra.Options, &ndp.DNSSearchList{
-				Lifetime:    p.Lifetime,
-				DomainNames: p.DomainNames,
+				Lifetime:    lifetime,
+				DomainNames: names,
 			})
+		case *config.HomeAgent:
+			// If auto, use the router lifetime as recommended by the RFC.
+			// A lifetime of zero is not permitted, so fall back to the same
+			// lifetime used for other options.
+			if p.Lifetime == config.DurationAuto {
+				p.Lifetime = ifi.DefaultLifetime
+				if p.Lifetime == 0 {
+					p.Lifetime = 3 * ifi.MaxInterval
+				}
+			}
+
+			opt, err := homeAgentInformation(p.Preference, p.Lifetime)
+			if err != nil {
+				return nil, err
+			}
+
+			// The option is only valid when the home agent flag is set, per:
+			//  https://tools.ietf.org/html/rfc6275#section-7.4.
+			ra.MobileIPv6HomeAgent = true
+			ra.Options = append(ra.Options, opt)
 		case *config.MTU:
 			ra.Options = append(ra.Options, ndp.NewMTU(uint32(*p)))
+		case *config.PREF64:
+			// If auto, compute lifetime as recommended by the RFC.
+			if p.Lifetime == config.DurationAuto {
+				p.Lifetime = 3 * ifi.MaxInterval
+			}
+
+			opt, err := pref64(p.Prefix, p.Lifetime)
+			if err != nil {
+				return nil, err
+			}
+
+			ra.Options = append(ra.Options, opt)
 		case *config.Prefix:
 			opts, err := b.prefixInformation(p)
 			if err != nil {
@@ -59,78 +164,416 @@ func (b *builder) Build(ifi config.Interface) (*ndp.RouterAdvertisement, error)
 			}
 
 			ra.Options = append(ra.Options, opts...)
+		case *config.Raw:
+			opt, err := rawOption(p.Type, p.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			ra.Options = append(ra.Options, opt)
 		case *config.RDNSS:
 			// If auto, compute lifetime as recommended by the RFC.
 			if p.Lifetime == config.DurationAuto {
 				p.Lifetime = 3 * ifi.MaxInterval
 			}
 
+			servers, err := b.rdnssServers(p)
+			if err != nil {
+				return nil, err
+			}
+
+			// An automatic configuration may not have found any values yet.
+			if len(servers) == 0 {
+				break
+			}
+
+			lifetime := p.Lifetime
+			if p.DecrementLifetimes {
+				if lifetime = b.decrement(lifetime); lifetime == 0 {
+					break
+				}
+			}
+
 			ra.Options = append(ra.Options, &ndp.RecursiveDNSServer{
-				Lifetime: p.Lifetime,
-				Servers:  p.Servers,
+				Lifetime: lifetime,
+				Servers:  servers,
 			})
+		case *config.Route:
+			// If auto, compute lifetime as recommended by the RFC.
+			if p.Lifetime == config.DurationAuto {
+				p.Lifetime = 3 * ifi.MaxInterval
+			}
+
+			opts, err := b.routeInformation(p)
+			if err != nil {
+				return nil, err
+			}
+
+			ra.Options = append(ra.Options, opts...)
+		}
+	}
+
+	if ifi.AdvertisementIntervalOption {
+		// Always advertise the configured maximum, even when unsolicited
+		// advertisements are sent more frequently during initialization, per:
+		//  https://tools.ietf.org/html/rfc6275#section-7.3.
+		opt, err := advertisementInterval(ifi.MaxInterval)
+		if err != nil {
+			return nil, err
 		}
+
+		ra.Options = append(ra.Options, opt)
 	}
 
 	return ra, nil
 }
 
-// prefixInformation produces ndp.PrefixInformation options for the prefix plugin.
-func (b *builder) prefixInformation(p *config.Prefix) ([]ndp.Option, error) {
-	length, _ := p.Prefix.Mask.Size()
+// rdnssServers produces the server addresses for the rdnss plugin, expanding
+// :: to all of this interface's addresses within the configured scope.
+func (b *builder) rdnssServers(r *config.RDNSS) ([]net.IP, error) {
+	if r.ResolvConf != "" {
+		return b.resolvConf(r.ResolvConf).Nameservers, nil
+	}
+
+	servers := make([]net.IP, 0, len(r.Servers))
+	for _, s := range r.Servers {
+		if !s.Equal(net.IPv6unspecified) {
+			// Use the specified server.
+			servers = append(servers, s)
+			continue
+		}
 
-	var prefixes []net.IP
-	if p.Prefix.IP.Equal(net.IPv6zero) {
-		// Expand ::/N to all unique, non-link local prefixes with matching
-		// length on this interface.
 		addrs, err := b.Addrs()
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch IP addresses: %v", err)
 		}
 
-		seen := make(map[string]struct{})
 		for _, a := range addrs {
-			// Only advertise non-link-local prefixes:
-			// https://tools.ietf.org/html/rfc4861#section-4.6.2.
 			ipn, ok := a.(*net.IPNet)
-			if !ok || ipn.IP.IsLinkLocalUnicast() {
+			if !ok || !r.Scope.Match(ipn.IP) {
 				continue
 			}
 
-			size, _ := ipn.Mask.Size()
-			if size != length {
-				continue
+			// Only add each address once.
+			var seen bool
+			for _, s := range servers {
+				if s.Equal(ipn.IP) {
+					seen = true
+					break
+				}
 			}
+			if !seen {
+				servers = append(servers, ipn.IP)
+			}
+		}
+	}
 
-			// Found a match, mask and keep the prefix bits of the address.
-			ip := ipn.IP.Mask(ipn.Mask)
+	return servers, nil
+}
 
-			// Only add each prefix once.
-			if _, ok := seen[ip.String()]; ok {
-				continue
-			}
-			seen[ip.String()] = struct{}{}
+// resolvConf fetches the contents of the resolv.conf file at path, logging
+// the values found whenever they change.
+//
+// A resolv.conf file may be briefly missing or unreadable while another
+// program replaces it, so a read failure is logged and the last values which
+// were read successfully are used instead. If no values were ever read, the
+// options derived from the file are omitted.
+func (b *builder) resolvConf(path string) *resolvConf {
+	rc, changed, err := b.ResolvConf(path)
+	if err != nil {
+		if b.Logf != nil {
+			b.Logf("failed to read %q, using last known values: %v", path, err)
+		}
+		if rc == nil {
+			rc = &resolvConf{}
+		}
 
-			prefixes = append(prefixes, ip)
+		return rc
+	}
+
+	if changed && b.Logf != nil {
+		ips := make([]string, 0, len(rc.Nameservers))
+		for _, ip := range rc.Nameservers {
+			ips = append(ips, ip.String())
 		}
+
+		b.Logf("loaded %q: IPv6 nameservers: [%s], search domains: [%s]",
+			path, strings.Join(ips, ", "), strings.Join(rc.Search, ", "))
+	}
+
+	return rc
+}
+
+// prefixInformation produces ndp.PrefixInformation options for the prefix plugin.
+func (b *builder) prefixInformation(p *config.Prefix) ([]ndp.Option, error) {
+	var (
+		prefixes         []net.IP
+		valid, preferred = p.ValidLifetime, p.PreferredLifetime
+		err              error
+	)
+
+	if p.DelegatedPrefixFile != "" {
+		prefixes, valid, preferred, err = b.delegatedPrefix(p)
 	} else {
-		// Use the specified prefix.
-		prefixes = append(prefixes, p.Prefix.IP)
+		prefixes, err = b.prefixes(p.Prefix)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	// Produce a PrefixInformation option for each configured prefix.
+	// Only serve the prefixes selected by the configured filters.
+	length, _ := p.Prefix.Mask.Size()
+	matched := prefixes[:0]
+	for _, pfx := range prefixes {
+		if p.Match(&net.IPNet{IP: pfx, Mask: net.CIDRMask(length, 128)}) {
+			matched = append(matched, pfx)
+		}
+	}
+	prefixes = matched
+
+	// Delegated prefixes are decremented by delegatedPrefix.
+	if p.DecrementLifetimes && p.DelegatedPrefixFile == "" {
+		valid, preferred = b.decrement(valid), b.decrement(preferred)
+	}
+
+	// Produce a PrefixInformation option for each configured prefix, unless
+	// its lifetime has been decremented to zero.
 	// All prefixes expanded from ::/N have the same configuration.
 	opts := make([]ndp.Option, 0, len(prefixes))
 	for _, pfx := range prefixes {
-		opts = append(opts, &ndp.PrefixInformation{
+		if valid == 0 {
+			break
+		}
+
+		pi := &ndp.PrefixInformation{
 			PrefixLength:                   uint8(length),
 			OnLink:                         p.OnLink,
 			AutonomousAddressConfiguration: p.Autonomous,
-			ValidLifetime:                  p.ValidLifetime,
-			PreferredLifetime:              p.PreferredLifetime,
+			ValidLifetime:                  valid,
+			PreferredLifetime:              preferred,
 			Prefix:                         pfx,
+		}
+
+		if p.RouterAddress {
+			// Advertise the router's full address within this prefix if one
+			// exists, or fall back to a regular prefix.
+			addr, err := b.routerAddress(&net.IPNet{
+				IP:   pfx,
+				Mask: net.CIDRMask(length, 128),
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			if addr != nil {
+				opt, err := routerAddressPrefix(pi, addr)
+				if err != nil {
+					return nil, err
+				}
+
+				opts = append(opts, opt)
+				continue
+			}
+		}
+
+		opts = append(opts, pi)
+	}
+
+	if b.Tracker == nil || !config.IsWildcard(p.Prefix) {
+		return opts, nil
+	}
+
+	// Prefixes which have disappeared from the interface are advertised with
+	// a preferred lifetime of zero so hosts stop using them for new
+	// connections, per:
+	//  https://tools.ietf.org/html/rfc8978#section-3.
+	for _, d := range b.Tracker.Update(p, prefixes, b.now()) {
+		opts = append(opts, &ndp.PrefixInformation{
+			PrefixLength:                   uint8(length),
+			OnLink:                         p.OnLink,
+			AutonomousAddressConfiguration: p.Autonomous,
+			ValidLifetime:                  d.ValidLifetime,
+			PreferredLifetime:              0,
+			Prefix:                         d.Prefix,
 		})
 	}
 
 	return opts, nil
 }
+
+// delegatedPrefix produces the prefix for a prefix plugin configured with a
+// delegated prefix file, and the lifetimes which should be advertised for it.
+func (b *builder) delegatedPrefix(p *config.Prefix) ([]net.IP, time.Duration, time.Duration, error) {
+	dp, changed, err := b.DelegatedPrefix(p.DelegatedPrefixFile)
+	switch {
+	case os.IsNotExist(err):
+		// No prefix has been delegated yet, or the delegation was withdrawn.
+		return nil, 0, 0, nil
+	case err != nil:
+		// The file may be briefly incomplete while a DHCPv6 client rewrites
+		// it, so keep serving the last prefix which was read successfully, if
+		// any, rather than failing to build the router advertisement.
+		if b.Logf != nil {
+			b.Logf("failed to read %q, using last known delegated prefix: %v", p.DelegatedPrefixFile, err)
+		}
+		if dp == nil {
+			return nil, 0, 0, nil
+		}
+	}
+
+	length, _ := p.Prefix.Mask.Size()
+	ip, err := subnet(dp.Prefix, length, p.Subnet)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to produce subnet of delegated prefix: %v", err)
+	}
+
+	if changed && b.Logf != nil {
+		b.Logf("loaded %q: delegated prefix: %s, preferred: %s, valid: %s, advertising: %s/%d",
+			p.DelegatedPrefixFile, dp.Prefix, dp.PreferredLifetime, dp.ValidLifetime, ip, length)
+	}
+
+	// The lease's lifetimes take precedence over those in the configuration,
+	// and always count down from the time the lease was obtained so that
+	// hosts never use the prefix beyond the lease's expiration.
+	if dp.ValidLifetime != 0 {
+		return []net.IP{ip},
+			b.remaining(dp.ValidLifetime, dp.Updated),
+			b.remaining(dp.PreferredLifetime, dp.Updated),
+			nil
+	}
+
+	valid, preferred := p.ValidLifetime, p.PreferredLifetime
+	if p.DecrementLifetimes {
+		valid, preferred = b.decrement(valid), b.decrement(preferred)
+	}
+
+	return []net.IP{ip}, valid, preferred, nil
+}
+
+// decrement computes the remaining lifetime of d, which counts down in real
+// time from b.Anchor. Lifetimes of zero and infinity are not decremented.
+func (b *builder) decrement(d time.Duration) time.Duration {
+	return b.remaining(d, b.Anchor)
+}
+
+// remaining computes the remaining lifetime of d, which counts down in real
+// time from start. Lifetimes of zero and infinity are not decremented.
+func (b *builder) remaining(d time.Duration, start time.Time) time.Duration {
+	if d == 0 || d == ndp.Infinity {
+		return d
+	}
+
+	// A start time in the future, such as after the system clock steps
+	// backward, does not extend a lifetime.
+	elapsed := b.now().Sub(start)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	// Round down so that hosts never believe a lifetime extends beyond its
+	// true expiration.
+	remain := (d - elapsed).Truncate(time.Second)
+	if remain < 0 {
+		return 0
+	}
+
+	return remain
+}
+
+// now returns the current time.
+func (b *builder) now() time.Time {
+	if b.Now != nil {
+		return b.Now()
+	}
+
+	return time.Now()
+}
+
+// routeInformation produces Route Information options for the route plugin.
+func (b *builder) routeInformation(r *config.Route) ([]ndp.Option, error) {
+	prefixes, err := b.prefixes(r.Prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// All prefixes expanded from ::/N have the same configuration.
+	length, _ := r.Prefix.Mask.Size()
+	opts := make([]ndp.Option, 0, len(prefixes))
+	for _, pfx := range prefixes {
+		ri, err := routeInformation(pfx, length, r.Preference, r.Lifetime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build route information: %v", err)
+		}
+
+		opts = append(opts, ri)
+	}
+
+	return opts, nil
+}
+
+// routerAddress returns the first non-link-local IPv6 address on this
+// interface which resides within prefix, or nil if none exists.
+func (b *builder) routerAddress(prefix *net.IPNet) (net.IP, error) {
+	addrs, err := b.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch IP addresses: %v", err)
+	}
+
+	for _, a := range addrs {
+		ipn, ok := a.(*net.IPNet)
+		if !ok || ipn.IP.To4() != nil || ipn.IP.IsLinkLocalUnicast() {
+			continue
+		}
+
+		if prefix.Contains(ipn.IP) {
+			return ipn.IP, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// prefixes produces the prefixes for a configured IPv6 prefix, expanding
+// ::/N to all unique, non-link local prefixes with matching length on this
+// interface.
+func (b *builder) prefixes(ipn *net.IPNet) ([]net.IP, error) {
+	if !config.IsWildcard(ipn) {
+		// Use the specified prefix.
+		return []net.IP{ipn.IP}, nil
+	}
+	length, _ := ipn.Mask.Size()
+
+	addrs, err := b.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch IP addresses: %v", err)
+	}
+
+	var prefixes []net.IP
+	seen := make(map[string]struct{})
+	for _, a := range addrs {
+		// Only advertise non-link-local prefixes:
+		// https://tools.ietf.org/html/rfc4861#section-4.6.2.
+		ipn, ok := a.(*net.IPNet)
+		if !ok || ipn.IP.IsLinkLocalUnicast() {
+			continue
+		}
+
+		size, _ := ipn.Mask.Size()
+		if size != length {
+			continue
+		}
+
+		// Found a match, mask and keep the prefix bits of the address.
+		ip := ipn.IP.Mask(ipn.Mask)
+
+		// Only add each prefix once.
+		if _, ok := seen[ip.String()]; ok {
+			continue
+		}
+		seen[ip.String()] = struct{}{}
+
+		prefixes = append(prefixes, ip)
+	}
+
+	return prefixes, nil
+}
@@ -14,8 +14,10 @@
 package corerad
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"os"
 	"testing"
 	"time"
 
@@ -38,22 +40,667 @@ func Test_builderBuild(t *testing.T) {
 		{
 			name: "interface",
 			ifi: config.Interface{
-				HopLimit:        64,
-				DefaultLifetime: 3 * time.Second,
-				Managed:         true,
-				OtherConfig:     true,
-				ReachableTime:   30 * time.Second,
-				RetransmitTimer: 1 * time.Second,
+				HopLimit:               64,
+				DefaultLifetime:        3 * time.Second,
+				Managed:                true,
+				OtherConfig:            true,
+				MobileIPv6HomeAgent:    true,
+				NeighborDiscoveryProxy: true,
+				Preference:             ndp.High,
+				ReachableTime:          30 * time.Second,
+				RetransmitTimer:        1 * time.Second,
 			},
 			ra: &ndp.RouterAdvertisement{
-				CurrentHopLimit:      64,
-				RouterLifetime:       3 * time.Second,
-				ManagedConfiguration: true,
-				OtherConfiguration:   true,
-				ReachableTime:        30 * time.Second,
-				RetransmitTimer:      1 * time.Second,
+				CurrentHopLimit:           64,
+				RouterLifetime:            3 * time.Second,
+				ManagedConfiguration:      true,
+				OtherConfiguration:        true,
+				MobileIPv6HomeAgent:       true,
+				NeighborDiscoveryProxy:    true,
+				RouterSelectionPreference: ndp.High,
+				ReachableTime:             30 * time.Second,
+				RetransmitTimer:           1 * time.Second,
 			},
 		},
+		{
+			name: "preference not default router",
+			ifi: config.Interface{
+				Preference: ndp.Low,
+			},
+			ra: &ndp.RouterAdvertisement{
+				RouterSelectionPreference: ndp.Medium,
+			},
+		},
+		{
+			name: "advertisement interval",
+			ifi: config.Interface{
+				MaxInterval:                 10 * time.Minute,
+				AdvertisementIntervalOption: true,
+				Plugins: []config.Plugin{
+					newMTU(1500),
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					ndp.NewMTU(1500),
+					&ndp.RawOption{
+						Type:   optAdvertisementInterval,
+						Length: 1,
+						Value:  []byte{0x00, 0x00, 0x00, 0x09, 0x27, 0xc0},
+					},
+				},
+			},
+		},
+		{
+			name: "captive portal",
+			ifi: config.Interface{
+				Plugins: []config.Plugin{
+					&config.CaptivePortal{
+						URI: "https://example.com/capport",
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					&ndp.RawOption{
+						Type:   optCaptivePortal,
+						Length: 4,
+						// URI padded with NUL bytes to 8 octet boundary.
+						Value: append([]byte("https://example.com/capport"), 0x00, 0x00, 0x00),
+					},
+				},
+			},
+		},
+		{
+			name: "raw",
+			ifi: config.Interface{
+				Plugins: []config.Plugin{
+					&config.Raw{
+						Type:  253,
+						Value: []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05},
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					&ndp.RawOption{
+						Type:   253,
+						Length: 1,
+						Value:  []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05},
+					},
+				},
+			},
+		},
+		{
+			name: "DNR",
+			ifi: config.Interface{
+				Plugins: []config.Plugin{
+					&config.DNR{
+						ServicePriority:          1,
+						AuthenticationDomainName: "dns.example.com",
+						Servers:                  []net.IP{mustIP("2001:db8::1")},
+						ALPN:                     []string{"dot"},
+						Port:                     853,
+						Lifetime:                 10 * time.Second,
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					&ndp.RawOption{
+						Type:   optDNR,
+						Length: 8,
+						Value: []byte{
+							// Service priority and lifetime.
+							0x00, 0x01,
+							0x00, 0x00, 0x00, 0x0a,
+							// ADN length and ADN.
+							0x00, 0x11,
+							0x03, 'd', 'n', 's',
+							0x07, 'e', 'x', 'a', 'm', 'p', 'l', 'e',
+							0x03, 'c', 'o', 'm',
+							0x00,
+							// Address length and addresses.
+							0x00, 0x10,
+							0x20, 0x01, 0x0d, 0xb8, 0x00, 0x00, 0x00, 0x00,
+							0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+							// SvcParams length and SvcParams: alpn, port.
+							0x00, 0x0e,
+							0x00, 0x01, 0x00, 0x04, 0x03, 'd', 'o', 't',
+							0x00, 0x03, 0x00, 0x02, 0x03, 0x55,
+							// Padding.
+							0x00, 0x00, 0x00,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "DNR auto",
+			ifi: config.Interface{
+				MaxInterval: 10 * time.Second,
+				Plugins: []config.Plugin{
+					&config.DNR{
+						ServicePriority:          2,
+						AuthenticationDomainName: "doh.example.",
+						Servers:                  []net.IP{mustIP("2001:db8::2")},
+						ALPN:                     []string{"h2"},
+						DoHPath:                  "/q{?dns}",
+						Lifetime:                 config.DurationAuto,
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					&ndp.RawOption{
+						Type:   optDNR,
+						Length: 8,
+						Value: []byte{
+							// Service priority and lifetime.
+							0x00, 0x02,
+							0x00, 0x00, 0x00, 0x1e,
+							// ADN length and ADN.
+							0x00, 0x0d,
+							0x03, 'd', 'o', 'h',
+							0x07, 'e', 'x', 'a', 'm', 'p', 'l', 'e',
+							0x00,
+							// Address length and addresses.
+							0x00, 0x10,
+							0x20, 0x01, 0x0d, 0xb8, 0x00, 0x00, 0x00, 0x00,
+							0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02,
+							// SvcParams length and SvcParams: alpn, dohpath.
+							0x00, 0x13,
+							0x00, 0x01, 0x00, 0x03, 0x02, 'h', '2',
+							0x00, 0x07, 0x00, 0x08, '/', 'q', '{', '?', 'd', 'n', 's', '}',
+							// Padding.
+							0x00, 0x00,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "home agent",
+			ifi: config.Interface{
+				DefaultLifetime: 30 * time.Minute,
+				Plugins: []config.Plugin{
+					&config.HomeAgent{
+						Preference: -2,
+						Lifetime:   config.DurationAuto,
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				MobileIPv6HomeAgent: true,
+				RouterLifetime:      30 * time.Minute,
+				Options: []ndp.Option{
+					&ndp.RawOption{
+						Type:   optHomeAgentInformation,
+						Length: 1,
+						Value: []byte{
+							0x00, 0x00,
+							0xff, 0xfe,
+							0x07, 0x08,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "home agent not default router",
+			ifi: config.Interface{
+				MaxInterval: 10 * time.Second,
+				Plugins: []config.Plugin{
+					&config.HomeAgent{
+						Preference: 10,
+						Lifetime:   config.DurationAuto,
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				MobileIPv6HomeAgent: true,
+				Options: []ndp.Option{
+					&ndp.RawOption{
+						Type:   optHomeAgentInformation,
+						Length: 1,
+						Value: []byte{
+							0x00, 0x00,
+							0x00, 0x0a,
+							0x00, 0x1e,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "router address prefixes",
+			b: builder{
+				Addrs: func() ([]net.Addr, error) {
+					return []net.Addr{
+						&net.IPNet{
+							IP:   mustIP("fe80::1"),
+							Mask: net.CIDRMask(64, 128),
+						},
+						&net.IPNet{
+							IP:   mustIP("2001:db8::1"),
+							Mask: net.CIDRMask(64, 128),
+						},
+					}, nil
+				},
+			},
+			ifi: config.Interface{
+				Plugins: []config.Plugin{
+					&config.Prefix{
+						Prefix:            mustCIDR("::/64"),
+						OnLink:            true,
+						RouterAddress:     true,
+						PreferredLifetime: 10 * time.Second,
+						ValidLifetime:     20 * time.Second,
+					},
+					// No address within this prefix, so a regular prefix
+					// is advertised.
+					&config.Prefix{
+						Prefix:            mustCIDR("2001:db8:ffff::/64"),
+						Autonomous:        true,
+						RouterAddress:     true,
+						PreferredLifetime: 10 * time.Second,
+						ValidLifetime:     20 * time.Second,
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					&routerAddressOption{&ndp.RawOption{
+						Type:   optPrefixInformation,
+						Length: 4,
+						Value: []byte{
+							64, 0xa0,
+							0x00, 0x00, 0x00, 0x14,
+							0x00, 0x00, 0x00, 0x0a,
+							0x00, 0x00, 0x00, 0x00,
+							0x20, 0x01, 0x0d, 0xb8, 0x00, 0x00, 0x00, 0x00,
+							0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+						},
+					}},
+					&ndp.PrefixInformation{
+						PrefixLength:                   64,
+						AutonomousAddressConfiguration: true,
+						PreferredLifetime:              10 * time.Second,
+						ValidLifetime:                  20 * time.Second,
+						Prefix:                         mustIP("2001:db8:ffff::"),
+					},
+				},
+			},
+		},
+		{
+			name: "RDNSS wildcard",
+			b: builder{
+				Addrs: func() ([]net.Addr, error) {
+					return []net.Addr{
+						&net.IPNet{
+							IP:   mustIP("fe80::1"),
+							Mask: net.CIDRMask(64, 128),
+						},
+						&net.IPNet{
+							IP:   mustIP("2001:db8::1"),
+							Mask: net.CIDRMask(64, 128),
+						},
+						&net.IPNet{
+							IP:   mustIP("fd00::1"),
+							Mask: net.CIDRMask(64, 128),
+						},
+						// Duplicate and IPv4 addresses should be ignored.
+						&net.IPNet{
+							IP:   mustIP("2001:db8::1"),
+							Mask: net.CIDRMask(128, 128),
+						},
+						&net.IPNet{
+							IP:   net.IPv4(192, 0, 2, 1),
+							Mask: net.CIDRMask(24, 32),
+						},
+					}, nil
+				},
+			},
+			ifi: config.Interface{
+				Plugins: []config.Plugin{
+					&config.RDNSS{
+						Lifetime: 10 * time.Second,
+						Servers: []net.IP{
+							mustIP("2001:db8::53"),
+							net.IPv6unspecified,
+						},
+					},
+					&config.RDNSS{
+						Lifetime: 10 * time.Second,
+						Servers:  []net.IP{net.IPv6unspecified},
+						Scope:    config.ScopeUniqueLocal,
+					},
+					&config.RDNSS{
+						Lifetime: 10 * time.Second,
+						Servers:  []net.IP{net.IPv6unspecified},
+						Scope:    config.ScopeGlobal,
+					},
+					&config.RDNSS{
+						Lifetime: 10 * time.Second,
+						Servers:  []net.IP{net.IPv6unspecified},
+						Scope:    config.ScopeLinkLocal,
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					&ndp.RecursiveDNSServer{
+						Lifetime: 10 * time.Second,
+						Servers: []net.IP{
+							mustIP("2001:db8::53"),
+							mustIP("fe80::1"),
+							mustIP("2001:db8::1"),
+							mustIP("fd00::1"),
+						},
+					},
+					&ndp.RecursiveDNSServer{
+						Lifetime: 10 * time.Second,
+						Servers:  []net.IP{mustIP("fd00::1")},
+					},
+					&ndp.RecursiveDNSServer{
+						Lifetime: 10 * time.Second,
+						Servers:  []net.IP{mustIP("2001:db8::1")},
+					},
+					&ndp.RecursiveDNSServer{
+						Lifetime: 10 * time.Second,
+						Servers:  []net.IP{mustIP("fe80::1")},
+					},
+				},
+			},
+		},
+		{
+			name: "decrement lifetimes",
+			b: builder{
+				Anchor: time.Unix(0, 0),
+				Now: func() time.Time {
+					return time.Unix(0, 0).Add(15*time.Minute + 500*time.Millisecond)
+				},
+			},
+			ifi: config.Interface{
+				Plugins: []config.Plugin{
+					&config.Prefix{
+						Prefix:             mustCIDR("2001:db8::/64"),
+						OnLink:             true,
+						PreferredLifetime:  10 * time.Minute,
+						ValidLifetime:      20 * time.Minute,
+						DecrementLifetimes: true,
+					},
+					// Expired, so the option is omitted.
+					&config.Prefix{
+						Prefix:             mustCIDR("2001:db8:1::/64"),
+						OnLink:             true,
+						PreferredLifetime:  10 * time.Minute,
+						ValidLifetime:      15 * time.Minute,
+						DecrementLifetimes: true,
+					},
+					&config.RDNSS{
+						Lifetime:           10 * time.Minute,
+						Servers:            []net.IP{mustIP("2001:db8::1")},
+						DecrementLifetimes: true,
+					},
+					&config.DNSSL{
+						Lifetime:           20 * time.Minute,
+						DomainNames:        []string{"example.com"},
+						DecrementLifetimes: true,
+					},
+					// Infinite lifetimes never decrement.
+					&config.DNSSL{
+						Lifetime:           ndp.Infinity,
+						DomainNames:        []string{"example.org"},
+						DecrementLifetimes: true,
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					&ndp.PrefixInformation{
+						PrefixLength:      64,
+						OnLink:            true,
+						PreferredLifetime: 0,
+						ValidLifetime:     4*time.Minute + 59*time.Second,
+						Prefix:            mustIP("2001:db8::"),
+					},
+					&ndp.DNSSearchList{
+						Lifetime:    4*time.Minute + 59*time.Second,
+						DomainNames: []string{"example.com"},
+					},
+					&ndp.DNSSearchList{
+						Lifetime:    ndp.Infinity,
+						DomainNames: []string{"example.org"},
+					},
+				},
+			},
+		},
+		{
+			name: "delegated prefix",
+			b: builder{
+				DelegatedPrefix: func(path string) (*delegatedPrefix, bool, error) {
+					switch path {
+					case "/run/pd.json":
+						return &delegatedPrefix{
+							Prefix:            mustCIDR("2001:db8:0:100::/56"),
+							PreferredLifetime: time.Hour,
+							ValidLifetime:     2 * time.Hour,
+							Updated:           time.Unix(0, 0),
+						}, true, nil
+					case "/run/pd-static.json":
+						return &delegatedPrefix{
+							Prefix: mustCIDR("2001:db8:1::/48"),
+						}, false, nil
+					}
+
+					return nil, false, os.ErrNotExist
+				},
+				Now: func() time.Time { return time.Unix(0, 0) },
+			},
+			ifi: config.Interface{
+				Plugins: []config.Plugin{
+					&config.Prefix{
+						Prefix:              mustCIDR("::/64"),
+						OnLink:              true,
+						PreferredLifetime:   10 * time.Minute,
+						ValidLifetime:       20 * time.Minute,
+						DelegatedPrefixFile: "/run/pd.json",
+						Subnet:              2,
+					},
+					// The lease does not specify lifetimes.
+					&config.Prefix{
+						Prefix:              mustCIDR("::/64"),
+						Autonomous:          true,
+						PreferredLifetime:   10 * time.Minute,
+						ValidLifetime:       20 * time.Minute,
+						DelegatedPrefixFile: "/run/pd-static.json",
+						Subnet:              0xff,
+					},
+					// No prefix has been delegated yet.
+					&config.Prefix{
+						Prefix:              mustCIDR("::/64"),
+						PreferredLifetime:   10 * time.Minute,
+						ValidLifetime:       20 * time.Minute,
+						DelegatedPrefixFile: "/run/pd-missing.json",
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					&ndp.PrefixInformation{
+						PrefixLength:      64,
+						OnLink:            true,
+						PreferredLifetime: time.Hour,
+						ValidLifetime:     2 * time.Hour,
+						Prefix:            mustIP("2001:db8:0:102::"),
+					},
+					&ndp.PrefixInformation{
+						PrefixLength:                   64,
+						AutonomousAddressConfiguration: true,
+						PreferredLifetime:              10 * time.Minute,
+						ValidLifetime:                  20 * time.Minute,
+						Prefix:                         mustIP("2001:db8:1:ff::"),
+					},
+				},
+			},
+		},
+		{
+			name: "delegated prefix corrupt file",
+			b: builder{
+				DelegatedPrefix: func(path string) (*delegatedPrefix, bool, error) {
+					err := errors.New("unexpected end of JSON input")
+					if path == "/run/pd-new.json" {
+						// Nothing was ever read successfully.
+						return nil, false, err
+					}
+
+					// The last good contents are returned with the error.
+					return &delegatedPrefix{
+						Prefix:            mustCIDR("2001:db8:0:100::/56"),
+						PreferredLifetime: time.Hour,
+						ValidLifetime:     2 * time.Hour,
+						Updated:           time.Unix(0, 0),
+					}, false, err
+				},
+				Now: func() time.Time { return time.Unix(0, 0).Add(time.Minute) },
+			},
+			ifi: config.Interface{
+				DefaultLifetime: 30 * time.Minute,
+				Plugins: []config.Plugin{
+					&config.Prefix{
+						Prefix:              mustCIDR("::/64"),
+						OnLink:              true,
+						DelegatedPrefixFile: "/run/pd.json",
+						Subnet:              1,
+					},
+					&config.Prefix{
+						Prefix:              mustCIDR("::/64"),
+						OnLink:              true,
+						DelegatedPrefixFile: "/run/pd-new.json",
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				RouterLifetime: 30 * time.Minute,
+				Options: []ndp.Option{
+					&ndp.PrefixInformation{
+						PrefixLength:      64,
+						OnLink:            true,
+						PreferredLifetime: 59 * time.Minute,
+						ValidLifetime:     time.Hour + 59*time.Minute,
+						Prefix:            mustIP("2001:db8:0:101::"),
+					},
+				},
+			},
+		},
+		{
+			name: "resolv.conf",
+			b: builder{
+				ResolvConf: func(path string) (*resolvConf, bool, error) {
+					if path != "/etc/resolv.conf" {
+						panicf("unexpected resolv.conf path: %q", path)
+					}
+
+					return &resolvConf{
+						Nameservers: []net.IP{mustIP("2001:db8::1")},
+						Search:      []string{"example.com"},
+					}, true, nil
+				},
+			},
+			ifi: config.Interface{
+				Plugins: []config.Plugin{
+					&config.RDNSS{
+						Lifetime:   10 * time.Second,
+						ResolvConf: "/etc/resolv.conf",
+					},
+					&config.DNSSL{
+						Lifetime:   10 * time.Second,
+						ResolvConf: "/etc/resolv.conf",
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					&ndp.RecursiveDNSServer{
+						Lifetime: 10 * time.Second,
+						Servers:  []net.IP{mustIP("2001:db8::1")},
+					},
+					&ndp.DNSSearchList{
+						Lifetime:    10 * time.Second,
+						DomainNames: []string{"example.com"},
+					},
+				},
+			},
+		},
+		{
+			name: "resolv.conf empty",
+			b: builder{
+				ResolvConf: func(_ string) (*resolvConf, bool, error) {
+					return &resolvConf{}, false, nil
+				},
+			},
+			ifi: config.Interface{
+				Plugins: []config.Plugin{
+					&config.RDNSS{
+						Lifetime:   10 * time.Second,
+						ResolvConf: "/etc/resolv.conf",
+					},
+					&config.DNSSL{
+						Lifetime:   10 * time.Second,
+						ResolvConf: "/etc/resolv.conf",
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{},
+		},
+		{
+			name: "resolv.conf error last good",
+			b: builder{
+				ResolvConf: func(_ string) (*resolvConf, bool, error) {
+					return &resolvConf{
+						Nameservers: []net.IP{mustIP("2001:db8::1")},
+					}, false, os.ErrNotExist
+				},
+			},
+			ifi: config.Interface{
+				Plugins: []config.Plugin{
+					&config.RDNSS{
+						Lifetime:   10 * time.Second,
+						ResolvConf: "/etc/resolv.conf",
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					&ndp.RecursiveDNSServer{
+						Lifetime: 10 * time.Second,
+						Servers:  []net.IP{mustIP("2001:db8::1")},
+					},
+				},
+			},
+		},
+		{
+			name: "resolv.conf error",
+			b: builder{
+				ResolvConf: func(_ string) (*resolvConf, bool, error) {
+					return nil, false, os.ErrNotExist
+				},
+			},
+			ifi: config.Interface{
+				Plugins: []config.Plugin{
+					&config.RDNSS{
+						Lifetime:   10 * time.Second,
+						ResolvConf: "/etc/resolv.conf",
+					},
+					&config.DNSSL{
+						Lifetime:   10 * time.Second,
+						ResolvConf: "/etc/resolv.conf",
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{},
+		},
 		{
 			name: "DNSSL",
 			ifi: config.Interface{
@@ -171,6 +818,43 @@ func Test_builderBuild(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "filtered prefixes",
+			b: builder{
+				Addrs: func() ([]net.Addr, error) {
+					return []net.Addr{
+						mustCIDR("2001:db8::1/64"),
+						mustCIDR("2001:db8:ffff::1/64"),
+						mustCIDR("2001:db9::1/64"),
+						mustCIDR("fd00::1/64"),
+					}, nil
+				},
+			},
+			ifi: config.Interface{
+				Plugins: []config.Plugin{
+					&config.Prefix{
+						Prefix:            mustCIDR("::/64"),
+						OnLink:            true,
+						PreferredLifetime: 10 * time.Second,
+						ValidLifetime:     20 * time.Second,
+						Scope:             config.ScopeGlobal,
+						Include:           []*net.IPNet{mustCIDR("2001:db8::/32")},
+						Exclude:           []*net.IPNet{mustCIDR("2001:db8:ffff::/48")},
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					&ndp.PrefixInformation{
+						PrefixLength:      64,
+						OnLink:            true,
+						PreferredLifetime: 10 * time.Second,
+						ValidLifetime:     20 * time.Second,
+						Prefix:            mustIP("2001:db8::"),
+					},
+				},
+			},
+		},
 		{
 			name: "MTU",
 			ifi: config.Interface{
@@ -229,6 +913,168 @@ func Test_builderBuild(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "PREF64",
+			ifi: config.Interface{
+				Plugins: []config.Plugin{
+					&config.PREF64{
+						Prefix:   mustCIDR("64:ff9b::/96"),
+						Lifetime: 10 * time.Minute,
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					&ndp.RawOption{
+						Type:   optPREF64,
+						Length: 2,
+						Value: []byte{
+							0x02, 0x58,
+							0x00, 0x64, 0xff, 0x9b,
+							0x00, 0x00, 0x00, 0x00,
+							0x00, 0x00, 0x00, 0x00,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "PREF64 auto",
+			ifi: config.Interface{
+				MaxInterval: 10 * time.Second,
+				Plugins: []config.Plugin{
+					&config.PREF64{
+						Prefix:   mustCIDR("2001:db8:64::/64"),
+						Lifetime: config.DurationAuto,
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					&ndp.RawOption{
+						Type:   optPREF64,
+						Length: 2,
+						Value: []byte{
+							// Lifetime rounded up to 32 seconds.
+							0x00, 0x21,
+							0x20, 0x01, 0x0d, 0xb8,
+							0x00, 0x64, 0x00, 0x00,
+							0x00, 0x00, 0x00, 0x00,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "static route",
+			ifi: config.Interface{
+				Plugins: []config.Plugin{
+					&config.Route{
+						Prefix:     mustCIDR("2001:db8::/32"),
+						Preference: ndp.High,
+						Lifetime:   10 * time.Second,
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					&routeInformationOption{&ndp.RawOption{
+						Type:   optRouteInformation,
+						Length: 2,
+						Value: []byte{
+							32, 0x08,
+							0x00, 0x00, 0x00, 0x0a,
+							0x20, 0x01, 0x0d, 0xb8, 0x00, 0x00, 0x00, 0x00,
+						},
+					}},
+				},
+			},
+		},
+		{
+			name: "default route auto",
+			ifi: config.Interface{
+				MaxInterval: 10 * time.Second,
+				Plugins: []config.Plugin{
+					&config.Route{
+						Prefix:     mustCIDR("::/0"),
+						Preference: ndp.Low,
+						Lifetime:   config.DurationAuto,
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					&routeInformationOption{&ndp.RawOption{
+						Type:   optRouteInformation,
+						Length: 1,
+						Value: []byte{
+							0, 0x18,
+							0x00, 0x00, 0x00, 0x1e,
+						},
+					}},
+				},
+			},
+		},
+		{
+			name: "host route",
+			ifi: config.Interface{
+				Plugins: []config.Plugin{
+					&config.Route{
+						Prefix:   mustCIDR("2001:db8::1/128"),
+						Lifetime: ndp.Infinity,
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					&routeInformationOption{&ndp.RawOption{
+						Type:   optRouteInformation,
+						Length: 3,
+						Value: []byte{
+							128, 0x00,
+							0xff, 0xff, 0xff, 0xff,
+							0x20, 0x01, 0x0d, 0xb8, 0x00, 0x00, 0x00, 0x00,
+							0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+						},
+					}},
+				},
+			},
+		},
+		{
+			name: "automatic routes",
+			b: builder{
+				Addrs: func() ([]net.Addr, error) {
+					return []net.Addr{
+						// Populate some addresses that should be ignored.
+						mustCIDR("fe80::1/64"),
+						mustCIDR("2001:db8::1/64"),
+						mustCIDR("fd00::1/48"),
+						mustCIDR("fd00::2/48"),
+					}, nil
+				},
+			},
+			ifi: config.Interface{
+				Plugins: []config.Plugin{
+					&config.Route{
+						Prefix:   mustCIDR("::/48"),
+						Lifetime: 10 * time.Second,
+					},
+				},
+			},
+			ra: &ndp.RouterAdvertisement{
+				Options: []ndp.Option{
+					&routeInformationOption{&ndp.RawOption{
+						Type:   optRouteInformation,
+						Length: 2,
+						Value: []byte{
+							48, 0x00,
+							0x00, 0x00, 0x00, 0x0a,
+							0xfd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+						},
+					}},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,104 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import "time"
+
+// solicitationThreshold is the number of router solicitations which may be
+// received within minDelayBetweenRAs before responses are coalesced into
+// multicast router advertisements.
+const solicitationThreshold = 10
+
+// A response indicates how a router advertisement is sent in response to a
+// router solicitation.
+type response int
+
+// Possible response values.
+const (
+	// Respond with a unicast RA.
+	respondUnicast response = iota
+	// Respond with a solicited multicast RA.
+	respondMulticast
+	// Respond with a multicast RA which is already scheduled.
+	respondCoalesced
+)
+
+// A multicastSchedule tracks when multicast router advertisements are sent so
+// that responses to router solicitations may be coalesced and rate limited,
+// as described in: https://tools.ietf.org/html/rfc4861#section-6.2.6.
+type multicastSchedule struct {
+	// last is when the most recent multicast RA is scheduled to be sent,
+	// which may be in the future.
+	last time.Time
+	// next is when the next unsolicited multicast RA is due.
+	next time.Time
+
+	// Tracks the rate of router solicitations.
+	window time.Time
+	n      int
+}
+
+// Unsolicited schedules an unsolicited multicast RA requested at now, and
+// records that the following unsolicited RA is due at next. It returns the
+// delay before the RA may be sent.
+func (ms *multicastSchedule) Unsolicited(now, next time.Time) time.Duration {
+	at := now
+	if min := ms.last.Add(minDelayBetweenRAs); at.Before(min) {
+		at = min
+	}
+
+	ms.last = at
+	ms.next = next
+
+	return at.Sub(now)
+}
+
+// Solicited determines how to respond to a router solicitation received at
+// now, given a random delay between 0 and maxRADelay. If unspecified is
+// true, the solicitation was sent from the unspecified address and must be
+// answered with a multicast RA. It returns the response and the delay before
+// any new RA may be sent.
+func (ms *multicastSchedule) Solicited(now time.Time, delay time.Duration, unspecified bool) (response, time.Duration) {
+	if now.Sub(ms.window) >= minDelayBetweenRAs {
+		ms.window = now
+		ms.n = 0
+	}
+	ms.n++
+
+	// If the random delay corresponds to a time later than the next
+	// multicast RA, send the response at the already scheduled time.
+	at := now.Add(delay)
+	for _, t := range []time.Time{ms.last, ms.next} {
+		if t.After(now) && !t.After(at) {
+			return respondCoalesced, 0
+		}
+	}
+
+	if !unspecified && ms.n <= solicitationThreshold {
+		return respondUnicast, delay
+	}
+
+	// A multicast response is required. Fold this solicitation into any
+	// pending multicast RA, or rate limit a new one to be sent no earlier
+	// than minDelayBetweenRAs plus the random delay after the previous one.
+	if ms.last.After(now) {
+		return respondCoalesced, 0
+	}
+	if min := ms.last.Add(minDelayBetweenRAs); at.Before(min) {
+		at = min.Add(delay)
+	}
+
+	ms.last = at
+	return respondMulticast, at.Sub(now)
+}
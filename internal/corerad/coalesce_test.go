@@ -0,0 +1,133 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_multicastScheduleSolicited(t *testing.T) {
+	var (
+		start = time.Unix(1000, 0)
+		delay = 100 * time.Millisecond
+	)
+
+	type solicit struct {
+		at          time.Duration
+		unspecified bool
+		res         response
+		delay       time.Duration
+	}
+
+	tests := []struct {
+		name string
+		// Unsolicited RA sent at start, and the next one due at this offset.
+		next time.Duration
+		rs   []solicit
+	}{
+		{
+			name: "unicast",
+			next: 10 * time.Second,
+			rs: []solicit{
+				{at: 5 * time.Second, res: respondUnicast, delay: delay},
+			},
+		},
+		{
+			name: "multicast due soon",
+			next: 10 * time.Second,
+			rs: []solicit{
+				{at: 10*time.Second - delay, res: respondCoalesced},
+			},
+		},
+		{
+			name: "unspecified rate limited",
+			next: 10 * time.Second,
+			rs: []solicit{
+				// Too soon after the unsolicited RA, so delay until
+				// minDelayBetweenRAs plus the random delay have elapsed.
+				{at: 1 * time.Second, unspecified: true, res: respondMulticast, delay: 2*time.Second + delay},
+				// Folded into the pending multicast RA.
+				{at: 2 * time.Second, unspecified: true, res: respondCoalesced},
+				{at: 2 * time.Second, res: respondUnicast, delay: delay},
+			},
+		},
+		{
+			name: "unspecified",
+			next: 10 * time.Second,
+			rs: []solicit{
+				{at: 5 * time.Second, unspecified: true, res: respondMulticast, delay: delay},
+			},
+		},
+		{
+			name: "threshold",
+			next: 60 * time.Second,
+			rs: func() []solicit {
+				var rs []solicit
+				for i := 0; i < solicitationThreshold; i++ {
+					rs = append(rs, solicit{at: 5 * time.Second, res: respondUnicast, delay: delay})
+				}
+
+				return append(rs,
+					solicit{at: 5 * time.Second, res: respondMulticast, delay: delay},
+					solicit{at: 5 * time.Second, res: respondCoalesced},
+					// The rate window has elapsed.
+					solicit{at: 9 * time.Second, res: respondUnicast, delay: delay},
+				)
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ms multicastSchedule
+			if d := ms.Unsolicited(start, start.Add(tt.next)); d != 0 {
+				t.Fatalf("unexpected initial unsolicited delay: %s", d)
+			}
+
+			for i, rs := range tt.rs {
+				res, d := ms.Solicited(start.Add(rs.at), delay, rs.unspecified)
+
+				want := []interface{}{rs.res, rs.delay}
+				if diff := cmp.Diff(want, []interface{}{res, d}); diff != "" {
+					t.Fatalf("unexpected response to RS %d (-want +got):\n%s", i, diff)
+				}
+			}
+		})
+	}
+}
+
+func Test_multicastScheduleUnsolicited(t *testing.T) {
+	var (
+		ms    multicastSchedule
+		start = time.Unix(1000, 0)
+	)
+
+	// A solicited multicast RA is pending, so the unsolicited RA must be
+	// spaced out after it.
+	if d := ms.Unsolicited(start, start.Add(10*time.Second)); d != 0 {
+		t.Fatalf("unexpected initial unsolicited delay: %s", d)
+	}
+
+	res, d := ms.Solicited(start.Add(5*time.Second), 0, true)
+	if res != respondMulticast || d != 0 {
+		t.Fatalf("unexpected solicited response: %v, %s", res, d)
+	}
+
+	if diff := cmp.Diff(3*time.Second, ms.Unsolicited(start.Add(5*time.Second), start.Add(15*time.Second))); diff != "" {
+		t.Fatalf("unexpected unsolicited delay (-want +got):\n%s", diff)
+	}
+}
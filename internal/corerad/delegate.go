@@ -0,0 +1,127 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"time"
+)
+
+// A delegatedPrefix is a prefix delegated to this router, such as by a
+// DHCPv6 Prefix Delegation client.
+type delegatedPrefix struct {
+	Prefix            *net.IPNet
+	PreferredLifetime time.Duration
+	ValidLifetime     time.Duration
+
+	// Updated is the time at which the lease was written, from which its
+	// lifetimes count down.
+	Updated time.Time
+}
+
+// parseDelegatedPrefix parses a delegatedPrefix from a JSON object such as:
+//
+//	{"prefix": "2001:db8::/56", "preferred_lifetime": 3600, "valid_lifetime": 7200}
+//
+// Lifetimes are specified in seconds and are optional.
+func parseDelegatedPrefix(r io.Reader) (*delegatedPrefix, error) {
+	var raw struct {
+		Prefix            string `json:"prefix"`
+		PreferredLifetime uint32 `json:"preferred_lifetime"`
+		ValidLifetime     uint32 `json:"valid_lifetime"`
+	}
+
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	ip, ipn, err := net.ParseCIDR(raw.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	if !ip.Equal(ipn.IP) || ip.To4() != nil {
+		return nil, fmt.Errorf("%q is not an IPv6 CIDR prefix", raw.Prefix)
+	}
+
+	dp := &delegatedPrefix{
+		Prefix:            ipn,
+		PreferredLifetime: time.Duration(raw.PreferredLifetime) * time.Second,
+		ValidLifetime:     time.Duration(raw.ValidLifetime) * time.Second,
+	}
+
+	if dp.PreferredLifetime > dp.ValidLifetime {
+		return nil, fmt.Errorf("preferred lifetime of %s exceeds valid lifetime of %s",
+			dp.PreferredLifetime, dp.ValidLifetime)
+	}
+
+	return dp, nil
+}
+
+// delegatedPrefixFile produces a function which returns the contents of a
+// delegated prefix file, and reports whether they changed since the previous
+// call. Files are only parsed again when they are modified, and the
+// modification time of a file is used as the time its lease was obtained. If
+// the file cannot be read, the last contents which were read successfully are
+// returned along with the error.
+func delegatedPrefixFile() func(path string) (*delegatedPrefix, bool, error) {
+	c := newFileCache(func(r io.Reader, modTime time.Time) (interface{}, error) {
+		dp, err := parseDelegatedPrefix(r)
+		if err != nil {
+			return nil, err
+		}
+
+		dp.Updated = modTime
+		return dp, nil
+	})
+
+	return func(path string) (*delegatedPrefix, bool, error) {
+		v, changed, err := c.Get(path)
+		if err != nil {
+			// Hand back the last good contents, if any, with the error.
+			dp, _ := v.(*delegatedPrefix)
+			return dp, false, err
+		}
+
+		return v.(*delegatedPrefix), changed, nil
+	}
+}
+
+// subnet produces subnet number n with the specified prefix length from
+// within prefix.
+func subnet(prefix *net.IPNet, length, n int) (net.IP, error) {
+	plen, _ := prefix.Mask.Size()
+	if length < plen {
+		return nil, fmt.Errorf("cannot produce /%d subnet of %s", length, prefix)
+	}
+
+	// There are 2^(length-plen) subnets available.
+	max := new(big.Int).Lsh(big.NewInt(1), uint(length-plen))
+	if big.NewInt(int64(n)).Cmp(max) >= 0 {
+		return nil, fmt.Errorf("subnet %d is out of range for /%d subnets of %s", n, length, prefix)
+	}
+
+	// Place the subnet number immediately after the delegated prefix bits.
+	ip := new(big.Int).SetBytes(prefix.IP.To16())
+	ip.Or(ip, new(big.Int).Lsh(big.NewInt(int64(n)), uint(128-length)))
+
+	b := ip.Bytes()
+	out := make(net.IP, net.IPv6len)
+	copy(out[net.IPv6len-len(b):], b)
+
+	return out, nil
+}
@@ -0,0 +1,315 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/corerad/internal/config"
+	"github.com/mdlayher/ndp"
+)
+
+func Test_parseDelegatedPrefix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		s    string
+		dp   *delegatedPrefix
+		ok   bool
+	}{
+		{
+			name: "bad JSON",
+			s:    "foo",
+		},
+		{
+			name: "bad prefix",
+			s:    `{"prefix": "foo"}`,
+		},
+		{
+			name: "bad prefix IP",
+			s:    `{"prefix": "2001:db8::1/56"}`,
+		},
+		{
+			name: "bad prefix IPv4",
+			s:    `{"prefix": "192.0.2.0/24"}`,
+		},
+		{
+			name: "bad lifetimes",
+			s:    `{"prefix": "2001:db8::/56", "preferred_lifetime": 2, "valid_lifetime": 1}`,
+		},
+		{
+			name: "OK no lifetimes",
+			s:    `{"prefix": "2001:db8::/56"}`,
+			dp: &delegatedPrefix{
+				Prefix: mustCIDR("2001:db8::/56"),
+			},
+			ok: true,
+		},
+		{
+			name: "OK",
+			s:    `{"prefix": "2001:db8::/56", "preferred_lifetime": 3600, "valid_lifetime": 7200}`,
+			dp: &delegatedPrefix{
+				Prefix:            mustCIDR("2001:db8::/56"),
+				PreferredLifetime: time.Hour,
+				ValidLifetime:     2 * time.Hour,
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dp, err := parseDelegatedPrefix(strings.NewReader(tt.s))
+			if tt.ok && err != nil {
+				t.Fatalf("failed to parse delegated prefix: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+			if err != nil {
+				t.Logf("err: %v", err)
+				return
+			}
+
+			if diff := cmp.Diff(tt.dp, dp); diff != "" {
+				t.Fatalf("unexpected delegated prefix (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_subnet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		prefix    string
+		length, n int
+		ip        net.IP
+	}{
+		{
+			name:   "too short",
+			prefix: "2001:db8::/56",
+			length: 48,
+		},
+		{
+			name:   "out of range",
+			prefix: "2001:db8::/56",
+			length: 64,
+			n:      256,
+		},
+		{
+			name:   "OK first",
+			prefix: "2001:db8:0:100::/56",
+			length: 64,
+			ip:     mustIP("2001:db8:0:100::"),
+		},
+		{
+			name:   "OK last",
+			prefix: "2001:db8:0:100::/56",
+			length: 64,
+			n:      255,
+			ip:     mustIP("2001:db8:0:1ff::"),
+		},
+		{
+			name:   "OK identical",
+			prefix: "2001:db8::/64",
+			length: 64,
+			ip:     mustIP("2001:db8::"),
+		},
+		{
+			name:   "OK /48 of /32",
+			prefix: "2001:db8::/32",
+			length: 48,
+			n:      0xabcd,
+			ip:     mustIP("2001:db8:abcd::"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, err := subnet(mustCIDR(tt.prefix), tt.length, tt.n)
+			if tt.ip == nil {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+
+				t.Logf("err: %v", err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to produce subnet: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.ip, ip); diff != "" {
+				t.Fatalf("unexpected subnet (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_delegatedPrefixFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "corerad-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		path = filepath.Join(dir, "pd.json")
+		fn   = delegatedPrefixFile()
+	)
+
+	// The lease was written an hour ago.
+	const s = `{"prefix": "2001:db8::/56", "preferred_lifetime": 3600, "valid_lifetime": 7200}`
+	if err := ioutil.WriteFile(path, []byte(s), 0o644); err != nil {
+		t.Fatalf("failed to write delegated prefix file: %v", err)
+	}
+
+	updated := time.Now().Add(-1 * time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, updated, updated); err != nil {
+		t.Fatalf("failed to set modification time: %v", err)
+	}
+
+	dp, _, err := fn(path)
+	if err != nil {
+		t.Fatalf("failed to get delegated prefix: %v", err)
+	}
+
+	want := &delegatedPrefix{
+		Prefix:            mustCIDR("2001:db8::/56"),
+		PreferredLifetime: time.Hour,
+		ValidLifetime:     2 * time.Hour,
+		Updated:           updated,
+	}
+
+	if diff := cmp.Diff(want, dp); diff != "" {
+		t.Fatalf("unexpected delegated prefix (-want +got):\n%s", diff)
+	}
+
+	// The file is partially written, so the last good contents are returned
+	// along with the error.
+	if err := ioutil.WriteFile(path, []byte(s[:10]), 0o644); err != nil {
+		t.Fatalf("failed to write delegated prefix file: %v", err)
+	}
+
+	dp, changed, err := fn(path)
+	if err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+	if diff := cmp.Diff(want, dp); diff != "" {
+		t.Fatalf("unexpected last good delegated prefix (-want +got):\n%s", diff)
+	}
+	if changed {
+		t.Fatal("delegated prefix should not be reported as changed")
+	}
+}
+
+func Test_builderDelegatedPrefixLifetimes(t *testing.T) {
+	t.Parallel()
+
+	var (
+		start = time.Unix(0, 0)
+		now   = start
+
+		dp = &delegatedPrefix{
+			Prefix:            mustCIDR("2001:db8:0:100::/56"),
+			PreferredLifetime: time.Hour,
+			ValidLifetime:     2 * time.Hour,
+			Updated:           start,
+		}
+	)
+
+	b := &builder{
+		DelegatedPrefix: func(_ string) (*delegatedPrefix, bool, error) {
+			return dp, false, nil
+		},
+		Now: func() time.Time { return now },
+		// CoreRAD started long after the lease was obtained, so lifetimes must
+		// not count down from here.
+		Anchor: start.Add(90 * time.Minute),
+	}
+
+	ifi := config.Interface{
+		Plugins: []config.Plugin{
+			&config.Prefix{
+				Prefix:              mustCIDR("::/64"),
+				PreferredLifetime:   10 * time.Minute,
+				ValidLifetime:       20 * time.Minute,
+				DecrementLifetimes:  true,
+				DelegatedPrefixFile: "/run/pd.json",
+				Subnet:              1,
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		updated time.Time
+		opts    []ndp.Option
+	}{
+		{
+			name:    "aged lease",
+			now:     start.Add(90*time.Minute + 500*time.Millisecond),
+			updated: start,
+			opts: []ndp.Option{&ndp.PrefixInformation{
+				PrefixLength:      64,
+				PreferredLifetime: 0,
+				ValidLifetime:     29*time.Minute + 59*time.Second,
+				Prefix:            mustIP("2001:db8:0:101::"),
+			}},
+		},
+		{
+			name:    "renewed lease",
+			now:     start.Add(100 * time.Minute),
+			updated: start.Add(95 * time.Minute),
+			opts: []ndp.Option{&ndp.PrefixInformation{
+				PrefixLength:      64,
+				PreferredLifetime: 55 * time.Minute,
+				ValidLifetime:     time.Hour + 55*time.Minute,
+				Prefix:            mustIP("2001:db8:0:101::"),
+			}},
+		},
+		{
+			name:    "expired lease",
+			now:     start.Add(3 * time.Hour),
+			updated: start,
+		},
+	}
+
+	for _, tt := range tests {
+		now = tt.now
+		dp.Updated = tt.updated
+
+		ra, err := b.Build(ifi)
+		if err != nil {
+			t.Fatalf("%s: failed to build RA: %v", tt.name, err)
+		}
+
+		if diff := cmp.Diff(tt.opts, ra.Options); diff != "" {
+			t.Fatalf("%s: unexpected options (-want +got):\n%s", tt.name, diff)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import (
+	"bytes"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/corerad/internal/config"
+)
+
+// A prefixTracker tracks the prefixes expanded from each configured ::/N
+// prefix, so prefixes which disappear from an interface can continue to be
+// advertised as deprecated, as described in RFC 8978.
+type prefixTracker struct {
+	mu       sync.Mutex
+	prefixes map[*config.Prefix]*trackedPrefixes
+}
+
+// trackedPrefixes are the prefixes tracked for a single config.Prefix.
+type trackedPrefixes struct {
+	// Prefixes advertised by the most recent update, and the times at which
+	// prefixes vanished from the interface.
+	current  map[string]net.IP
+	vanished map[string]vanishedPrefix
+}
+
+// A vanishedPrefix is a prefix which is no longer present on an interface.
+type vanishedPrefix struct {
+	Prefix net.IP
+	Since  time.Time
+}
+
+// A deprecatedPrefix is a prefix which should be advertised as deprecated
+// with the specified valid lifetime.
+type deprecatedPrefix struct {
+	Prefix        net.IP
+	ValidLifetime time.Duration
+}
+
+// newPrefixTracker creates a prefixTracker.
+func newPrefixTracker() *prefixTracker {
+	return &prefixTracker{
+		prefixes: make(map[*config.Prefix]*trackedPrefixes),
+	}
+}
+
+// Update records the prefixes currently present for p at time now, and returns
+// any previously present prefixes which must be advertised as deprecated.
+func (pt *prefixTracker) Update(p *config.Prefix, prefixes []net.IP, now time.Time) []deprecatedPrefix {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	if p.DeprecationPeriod == 0 {
+		// Deprecation disabled, nothing to track.
+		delete(pt.prefixes, p)
+		return nil
+	}
+
+	tp, ok := pt.prefixes[p]
+	if !ok {
+		tp = &trackedPrefixes{
+			current:  make(map[string]net.IP),
+			vanished: make(map[string]vanishedPrefix),
+		}
+		pt.prefixes[p] = tp
+	}
+
+	current := make(map[string]net.IP, len(prefixes))
+	for _, pfx := range prefixes {
+		k := pfx.String()
+		current[k] = pfx
+
+		// This prefix has returned, so it is no longer deprecated.
+		delete(tp.vanished, k)
+	}
+
+	for k, pfx := range tp.current {
+		if _, ok := current[k]; !ok {
+			tp.vanished[k] = vanishedPrefix{
+				Prefix: pfx,
+				Since:  now,
+			}
+		}
+	}
+	tp.current = current
+
+	var deprecated []deprecatedPrefix
+	for k, v := range tp.vanished {
+		// Count down the valid lifetime until the end of the deprecation
+		// period, rounding up so hosts are never told a lifetime of zero
+		// before the prefix is withdrawn.
+		remain := p.DeprecationPeriod - now.Sub(v.Since)
+		if remain <= 0 {
+			delete(tp.vanished, k)
+			continue
+		}
+
+		deprecated = append(deprecated, deprecatedPrefix{
+			Prefix:        v.Prefix,
+			ValidLifetime: (remain + time.Second - 1).Truncate(time.Second),
+		})
+	}
+
+	// Produce deterministic output for map iteration.
+	sort.Slice(deprecated, func(i, j int) bool {
+		return bytes.Compare(deprecated[i].Prefix, deprecated[j].Prefix) < 0
+	})
+
+	return deprecated
+}
+
+// Deprecated returns the number of prefixes which are currently deprecated.
+func (pt *prefixTracker) Deprecated() int {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	var n int
+	for _, tp := range pt.prefixes {
+		n += len(tp.vanished)
+	}
+
+	return n
+}
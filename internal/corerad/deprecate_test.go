@@ -0,0 +1,206 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/corerad/internal/config"
+	"github.com/mdlayher/ndp"
+)
+
+func Test_builderDeprecatePrefixes(t *testing.T) {
+	t.Parallel()
+
+	var (
+		now   = time.Unix(0, 0)
+		addrs []net.Addr
+	)
+
+	b := &builder{
+		Addrs:   func() ([]net.Addr, error) { return addrs, nil },
+		Now:     func() time.Time { return now },
+		Tracker: newPrefixTracker(),
+	}
+
+	ifi := config.Interface{
+		Plugins: []config.Plugin{
+			&config.Prefix{
+				Prefix:            mustCIDR("::/64"),
+				OnLink:            true,
+				PreferredLifetime: 10 * time.Minute,
+				ValidLifetime:     20 * time.Minute,
+				DeprecationPeriod: 5 * time.Minute,
+			},
+		},
+	}
+
+	pi := func(prefix string, preferred, valid time.Duration) *ndp.PrefixInformation {
+		return &ndp.PrefixInformation{
+			PrefixLength:      64,
+			OnLink:            true,
+			PreferredLifetime: preferred,
+			ValidLifetime:     valid,
+			Prefix:            mustIP(prefix),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		addrs      []string
+		advance    time.Duration
+		opts       []ndp.Option
+		deprecated int
+	}{
+		{
+			name:  "initial",
+			addrs: []string{"2001:db8::1/64", "2001:db8:1::1/64"},
+			opts: []ndp.Option{
+				pi("2001:db8::", 10*time.Minute, 20*time.Minute),
+				pi("2001:db8:1::", 10*time.Minute, 20*time.Minute),
+			},
+		},
+		{
+			name:  "renumbered",
+			addrs: []string{"2001:db8:2::1/64"},
+			opts: []ndp.Option{
+				pi("2001:db8:2::", 10*time.Minute, 20*time.Minute),
+				pi("2001:db8::", 0, 5*time.Minute),
+				pi("2001:db8:1::", 0, 5*time.Minute),
+			},
+			deprecated: 2,
+		},
+		{
+			name:    "counting down",
+			addrs:   []string{"2001:db8:2::1/64"},
+			advance: 2*time.Minute + 500*time.Millisecond,
+			opts: []ndp.Option{
+				pi("2001:db8:2::", 10*time.Minute, 20*time.Minute),
+				pi("2001:db8::", 0, 3*time.Minute),
+				pi("2001:db8:1::", 0, 3*time.Minute),
+			},
+			deprecated: 2,
+		},
+		{
+			name:    "prefix returns",
+			addrs:   []string{"2001:db8:1::1/64", "2001:db8:2::1/64"},
+			advance: time.Minute,
+			opts: []ndp.Option{
+				pi("2001:db8:1::", 10*time.Minute, 20*time.Minute),
+				pi("2001:db8:2::", 10*time.Minute, 20*time.Minute),
+				pi("2001:db8::", 0, 2*time.Minute),
+			},
+			deprecated: 1,
+		},
+		{
+			name:    "expired",
+			addrs:   []string{"2001:db8:1::1/64", "2001:db8:2::1/64"},
+			advance: 2 * time.Minute,
+			opts: []ndp.Option{
+				pi("2001:db8:1::", 10*time.Minute, 20*time.Minute),
+				pi("2001:db8:2::", 10*time.Minute, 20*time.Minute),
+			},
+		},
+	}
+
+	// Each test case depends on the state produced by the previous one.
+	for _, tt := range tests {
+		addrs = nil
+		for _, a := range tt.addrs {
+			ip, ipn, err := net.ParseCIDR(a)
+			if err != nil {
+				t.Fatalf("failed to parse CIDR: %v", err)
+			}
+			ipn.IP = ip
+
+			addrs = append(addrs, ipn)
+		}
+		now = now.Add(tt.advance)
+
+		ra, err := b.Build(ifi)
+		if err != nil {
+			t.Fatalf("%s: failed to build RA: %v", tt.name, err)
+		}
+
+		if diff := cmp.Diff(tt.opts, ra.Options); diff != "" {
+			t.Fatalf("%s: unexpected options (-want +got):\n%s", tt.name, diff)
+		}
+
+		if diff := cmp.Diff(tt.deprecated, b.Tracker.Deprecated()); diff != "" {
+			t.Fatalf("%s: unexpected deprecated prefixes (-want +got):\n%s", tt.name, diff)
+		}
+	}
+}
+
+func Test_builderDeprecateDelegatedPrefix(t *testing.T) {
+	t.Parallel()
+
+	dp := &delegatedPrefix{Prefix: mustCIDR("2001:db8:0:100::/56")}
+
+	b := &builder{
+		DelegatedPrefix: func(_ string) (*delegatedPrefix, bool, error) {
+			return dp, false, nil
+		},
+		Now:     func() time.Time { return time.Unix(0, 0) },
+		Tracker: newPrefixTracker(),
+	}
+
+	ifi := config.Interface{
+		Plugins: []config.Plugin{
+			&config.Prefix{
+				Prefix:              mustCIDR("::/64"),
+				PreferredLifetime:   10 * time.Minute,
+				ValidLifetime:       20 * time.Minute,
+				DeprecationPeriod:   5 * time.Minute,
+				DelegatedPrefixFile: "/run/pd.json",
+				Subnet:              1,
+			},
+		},
+	}
+
+	if _, err := b.Build(ifi); err != nil {
+		t.Fatalf("failed to build initial RA: %v", err)
+	}
+
+	// The upstream delegates a new prefix, so the subnet of the previous
+	// prefix must be deprecated.
+	dp = &delegatedPrefix{Prefix: mustCIDR("2001:db8:0:200::/56")}
+
+	ra, err := b.Build(ifi)
+	if err != nil {
+		t.Fatalf("failed to build RA: %v", err)
+	}
+
+	want := []ndp.Option{
+		&ndp.PrefixInformation{
+			PrefixLength:      64,
+			PreferredLifetime: 10 * time.Minute,
+			ValidLifetime:     20 * time.Minute,
+			Prefix:            mustIP("2001:db8:0:201::"),
+		},
+		&ndp.PrefixInformation{
+			PrefixLength:      64,
+			PreferredLifetime: 0,
+			ValidLifetime:     5 * time.Minute,
+			Prefix:            mustIP("2001:db8:0:101::"),
+		},
+	}
+
+	if diff := cmp.Diff(want, ra.Options); diff != "" {
+		t.Fatalf("unexpected options (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// A fileCache caches the parsed contents of files, and only parses a file
+// again when its modification time or size changes.
+type fileCache struct {
+	parse func(r io.Reader, modTime time.Time) (interface{}, error)
+
+	mu    sync.Mutex
+	files map[string]*fileEntry
+}
+
+// A fileEntry is the cached state of an individual file.
+type fileEntry struct {
+	modTime time.Time
+	size    int64
+	v       interface{}
+}
+
+// newFileCache creates a fileCache which uses parse to parse file contents.
+// parse also receives the modification time of the file.
+func newFileCache(parse func(r io.Reader, modTime time.Time) (interface{}, error)) *fileCache {
+	return &fileCache{
+		parse: parse,
+		files: make(map[string]*fileEntry),
+	}
+}
+
+// Get returns the parsed contents of the file at path, and reports whether
+// the contents changed since the previous call to Get for that file. If the
+// file cannot be read or parsed, Get returns the contents from the last
+// successful call, or nil, along with the error.
+func (c *fileCache) Get(path string) (interface{}, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev, ok := c.files[path]
+	var last interface{}
+	if ok {
+		last = prev.v
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return last, false, err
+	}
+
+	if ok && prev.modTime.Equal(fi.ModTime()) && prev.size == fi.Size() {
+		// No changes since the last parse.
+		return prev.v, false, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return last, false, err
+	}
+	defer f.Close()
+
+	v, err := c.parse(f, fi.ModTime())
+	if err != nil {
+		return last, false, err
+	}
+
+	c.files[path] = &fileEntry{
+		modTime: fi.ModTime(),
+		size:    fi.Size(),
+		v:       v,
+	}
+
+	// A file may be rewritten with identical contents, so only report a change
+	// when the parsed contents differ.
+	changed := !ok || !reflect.DeepEqual(prev.v, v)
+	return v, changed, nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import "sync"
+
+// A forwardingCache caches the IPv6 forwarding state of interfaces, so the
+// state need not be read from sysctls each time a router advertisement is
+// sent or metrics are collected.
+type forwardingCache struct {
+	mu sync.RWMutex
+	m  map[string]bool
+}
+
+// newForwardingCache creates an empty forwardingCache.
+func newForwardingCache() *forwardingCache {
+	return &forwardingCache{m: make(map[string]bool)}
+}
+
+// Get returns the cached forwarding state of an interface, and whether that
+// state is known.
+func (fc *forwardingCache) Get(iface string) (forwarding, ok bool) {
+	fc.mu.RLock()
+	defer fc.mu.RUnlock()
+
+	forwarding, ok = fc.m[iface]
+	return forwarding, ok
+}
+
+// Set sets the forwarding state of an interface and reports whether it differs
+// from a previously known state.
+func (fc *forwardingCache) Set(iface string, forwarding bool) (changed bool) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	prev, ok := fc.m[iface]
+	fc.m[iface] = forwarding
+
+	return ok && prev != forwarding
+}
+
+// Delete removes the cached forwarding state of an interface.
+func (fc *forwardingCache) Delete(iface string) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	delete(fc.m, iface)
+}
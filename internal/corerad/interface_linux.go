@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 // setIPv6Autoconf enables or disables IPv6 autoconfiguration for the
@@ -78,10 +80,27 @@ func sysctl(iface, key string) string {
 
 // sysctlEnable enable or disables a boolean sysctl.
 func sysctlEnable(iface, key string, enable bool) error {
-	in := []byte("0")
+	v := 0
 	if enable {
-		in = []byte("1")
+		v = 1
 	}
 
-	return ioutil.WriteFile(sysctl(iface, key), in, 0o644)
+	return setSysctl(iface, key, v)
+}
+
+// getSysctl reads an integer IPv6 sysctl for the given interface on Linux
+// systems.
+func getSysctl(iface, key string) (int, error) {
+	out, err := ioutil.ReadFile(sysctl(iface, key))
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+// setSysctl writes an integer IPv6 sysctl for the given interface on Linux
+// systems.
+func setSysctl(iface, key string, v int) error {
+	return ioutil.WriteFile(sysctl(iface, key), []byte(strconv.Itoa(v)), 0o644)
 }
@@ -15,7 +15,18 @@
 
 package corerad
 
-// These functions are no-op on non-Linux platforms.
+import (
+	"context"
+	"fmt"
+	"net"
+	"runtime"
+)
+
+// errSysctlUnsupported is returned when IPv6 sysctls are managed on a
+// platform which does not support them.
+var errSysctlUnsupported = fmt.Errorf("IPv6 sysctls are not supported on %s", runtime.GOOS)
+
+// These functions are no-op on non-Linux platforms, except where noted.
 
 func setIPv6Autoconf(_ string, _ bool) (bool, error) { return false, nil }
 
@@ -23,3 +34,39 @@ func getIPv6Forwarding(_ string) (bool, error) {
 	// Assume that an interface running CoreRAD is forwarding packets.
 	return true, nil
 }
+
+func getSysctl(_, _ string) (int, error) { return 0, errSysctlUnsupported }
+
+func setSysctl(_, _ string, _ int) error { return errSysctlUnsupported }
+
+func interfaceAddrs(ifi *net.Interface) ([]net.Addr, error) {
+	// Address flags are not available, so use all addresses.
+	return ifi.Addrs()
+}
+
+func linkLocalAddrs(ifi *net.Interface) ([]linkLocalAddr, error) {
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	// Duplicate address detection state is not available, so assume all
+	// link-local addresses are usable.
+	var lls []linkLocalAddr
+	for _, a := range addrs {
+		ipn, ok := a.(*net.IPNet)
+		if !ok || ipn.IP.To4() != nil || !ipn.IP.IsLinkLocalUnicast() {
+			continue
+		}
+
+		lls = append(lls, linkLocalAddr{IP: ipn.IP})
+	}
+
+	return lls, nil
+}
+
+func watchInterfaces(ctx context.Context, _ func(watchEvent)) error {
+	// Notifications are not available, so callers must poll.
+	<-ctx.Done()
+	return nil
+}
@@ -0,0 +1,241 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/mdlayher/corerad/internal/config"
+)
+
+// Lifecycle states of an interface, reported in metrics.
+const (
+	stateWaiting = "waiting"
+	stateRunning = "running"
+	stateStopped = "stopped"
+)
+
+// waitPollInterval is how often interface state is checked when rtnetlink
+// notifications are not available or may have been missed.
+const waitPollInterval = 5 * time.Second
+
+// linkLocalPollInterval is how often an interface's link-local addresses are
+// checked while waiting for duplicate address detection to complete.
+const linkLocalPollInterval = 100 * time.Millisecond
+
+// errDADFailed indicates that duplicate address detection failed for an
+// interface's IPv6 link-local address.
+var errDADFailed = errors.New("duplicate address detection failed")
+
+// A linkLocalAddr is an IPv6 link-local address and its duplicate address
+// detection state.
+type linkLocalAddr struct {
+	IP        net.IP
+	Tentative bool
+	DADFailed bool
+}
+
+// waitLinkLocal waits up to timeout for addrs to return an IPv6 link-local
+// address which has completed duplicate address detection, and returns it.
+// Addresses which fail duplicate address detection are logged using logf.
+// If ctx is canceled, waitLinkLocal returns immediately.
+func waitLinkLocal(
+	ctx context.Context,
+	addrs func() ([]linkLocalAddr, error),
+	timeout time.Duration,
+	logf func(format string, v ...interface{}),
+) (net.IP, error) {
+	var (
+		deadline = time.Now().Add(timeout)
+		failed   []net.IP
+	)
+
+	for {
+		lls, err := addrs()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch link-local addresses: %v", err)
+		}
+
+		for _, ll := range lls {
+			switch {
+			case ll.DADFailed:
+				if !containsIP(failed, ll.IP) {
+					logf("duplicate address detection failed for link-local address %s, waiting for another usable address", ll.IP)
+					failed = append(failed, ll.IP)
+				}
+			case !ll.Tentative:
+				return ll.IP, nil
+			}
+		}
+
+		if !time.Now().Before(deadline) {
+			if len(failed) > 0 {
+				return nil, fmt.Errorf("no usable IPv6 link-local address after %s: %w", timeout, errDADFailed)
+			}
+
+			return nil, fmt.Errorf("timed out after %s waiting for a usable IPv6 link-local address", timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("stopped waiting for a usable IPv6 link-local address: %w", ctx.Err())
+		case <-time.After(linkLocalPollInterval):
+		}
+	}
+}
+
+// containsIP reports whether ips contains ip.
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, v := range ips {
+		if v.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// superviseAdvertiser runs an Advertiser for cfg whenever its interface exists,
+// is up, and has a usable IPv6 link-local address, until ctx is canceled.
+// The Advertiser is stopped when the interface goes away, and started again
+// when it returns.
+func superviseAdvertiser(ctx context.Context, cfg config.Interface, ll *log.Logger, mm *AdvertiserMetrics, fc *forwardingCache) error {
+	logf := func(format string, v ...interface{}) {
+		ll.Println(cfg.Name + ": " + fmt.Sprintf(format, v...))
+	}
+
+	// Coalesce change notifications so the interface is checked again after
+	// any number of changes.
+	changeC := make(chan struct{}, 1)
+	notify := func(_ watchEvent) {
+		select {
+		case changeC <- struct{}{}:
+		default:
+		}
+	}
+
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	watchDone := make(chan struct{})
+	defer func() { <-watchDone }()
+	go func() {
+		defer close(watchDone)
+		if err := watchInterfaces(wctx, notify); err != nil {
+			logf("failed to watch for interface changes, polling instead: %v", err)
+		}
+	}()
+
+	// wait waits for a change notification or the poll interval, and reports
+	// whether ctx is still active.
+	wait := func() bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-changeC:
+		case <-time.After(waitPollInterval):
+		}
+
+		return true
+	}
+
+	for {
+		mm.setState(cfg.Name, stateWaiting)
+
+		ifi, ok := interfaceReady(cfg.Name)
+		if !ok {
+			if !wait() {
+				mm.setState(cfg.Name, stateStopped)
+				return nil
+			}
+			continue
+		}
+
+		ad, err := newAdvertiser(ctx, cfg, ll, mm, fc)
+		if err != nil {
+			if ctx.Err() == nil {
+				logf("failed to initialize, will retry: %v", err)
+			}
+			if !wait() {
+				mm.setState(cfg.Name, stateStopped)
+				return nil
+			}
+			continue
+		}
+
+		if err := runUntilGone(ctx, ad, ifi, changeC); err != nil {
+			logf("stopped advertising: %v", err)
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		logf("waiting for interface to become available")
+	}
+}
+
+// runUntilGone runs ad until ctx is canceled, ad fails, or the interface
+// ifi is no longer ready for use.
+func runUntilGone(ctx context.Context, ad *Advertiser, ifi *net.Interface, changeC <-chan struct{}) error {
+	actx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errC := make(chan error, 1)
+	go func() { errC <- ad.Advertise(actx) }()
+
+	for {
+		select {
+		case err := <-errC:
+			return err
+		case <-changeC:
+			// The interface may have been removed, or removed and created
+			// again with a new index.
+			if now, ok := interfaceReady(ifi.Name); ok && now.Index == ifi.Index {
+				continue
+			}
+
+			ad.logf("interface is no longer available, stopping")
+			cancel()
+			return <-errC
+		}
+	}
+}
+
+// interfaceReady determines if the interface with the specified name exists,
+// is up, and has a usable IPv6 link-local address.
+func interfaceReady(name string) (*net.Interface, bool) {
+	ifi, err := net.InterfaceByName(name)
+	if err != nil || ifi.Flags&net.FlagUp == 0 {
+		return nil, false
+	}
+
+	addrs, err := interfaceAddrs(ifi)
+	if err != nil {
+		return nil, false
+	}
+
+	for _, a := range addrs {
+		if ipn, ok := a.(*net.IPNet); ok && ipn.IP.IsLinkLocalUnicast() && ipn.IP.To4() == nil {
+			return ifi, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,147 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_waitLinkLocal(t *testing.T) {
+	var (
+		ll1 = mustIP("fe80::1")
+		ll2 = mustIP("fe80::2")
+		err = errors.New("some error")
+	)
+
+	tests := []struct {
+		name string
+		// Each call to addrs returns the next set of addresses, repeating
+		// the final set.
+		addrs [][]linkLocalAddr
+		err   error
+		ip    net.IP
+		logs  int
+		dad   bool
+		ok    bool
+	}{
+		{
+			name: "error",
+			err:  err,
+		},
+		{
+			name:  "no addresses",
+			addrs: [][]linkLocalAddr{nil},
+		},
+		{
+			name:  "tentative",
+			addrs: [][]linkLocalAddr{{{IP: ll1, Tentative: true}}},
+		},
+		{
+			name: "DAD failed",
+			addrs: [][]linkLocalAddr{{
+				{IP: ll1, Tentative: true, DADFailed: true},
+			}},
+			logs: 1,
+			dad:  true,
+		},
+		{
+			name:  "OK",
+			addrs: [][]linkLocalAddr{{{IP: ll1}}},
+			ip:    ll1,
+			ok:    true,
+		},
+		{
+			name: "OK after tentative",
+			addrs: [][]linkLocalAddr{
+				{{IP: ll1, Tentative: true}},
+				{{IP: ll1, Tentative: true}},
+				{{IP: ll1}},
+			},
+			ip: ll1,
+			ok: true,
+		},
+		{
+			name: "OK after DAD failed",
+			addrs: [][]linkLocalAddr{
+				{{IP: ll1, DADFailed: true}},
+				{{IP: ll1, DADFailed: true}, {IP: ll2, Tentative: true}},
+				{{IP: ll1, DADFailed: true}, {IP: ll2}},
+			},
+			ip:   ll2,
+			logs: 1,
+			ok:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var i int
+			addrs := func() ([]linkLocalAddr, error) {
+				if tt.err != nil {
+					return nil, tt.err
+				}
+
+				lls := tt.addrs[i]
+				if i < len(tt.addrs)-1 {
+					i++
+				}
+
+				return lls, nil
+			}
+
+			var logs int
+			logf := func(_ string, _ ...interface{}) { logs++ }
+
+			ip, err := waitLinkLocal(context.Background(), addrs, 5*linkLocalPollInterval, logf)
+			if tt.ok && err != nil {
+				t.Fatalf("failed to wait for link-local address: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+			if got := errors.Is(err, errDADFailed); got != tt.dad {
+				t.Fatalf("unexpected DAD failure error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.ip, ip); diff != "" {
+				t.Fatalf("unexpected IP address (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.logs, logs); diff != "" {
+				t.Fatalf("unexpected number of logs (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_waitLinkLocalCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	addrs := func() ([]linkLocalAddr, error) {
+		return []linkLocalAddr{{IP: mustIP("fe80::1"), Tentative: true}}, nil
+	}
+
+	// The timeout would never elapse, so cancelation must stop the wait.
+	_, err := waitLinkLocal(ctx, addrs, time.Hour, func(string, ...interface{}) {})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled error, but got: %v", err)
+	}
+}
@@ -20,11 +20,18 @@ import (
 
 // AdvertiserMetrics contains metrics for an Advertiser.
 type AdvertiserMetrics struct {
-	LastMulticastTime         *prometheus.GaugeVec
-	MessagesReceivedTotal     *prometheus.CounterVec
-	RouterAdvertisementsTotal *prometheus.CounterVec
-	ErrorsTotal               *prometheus.CounterVec
-	SchedulerWorkers          *prometheus.GaugeVec
+	LastMulticastTime                   *prometheus.GaugeVec
+	MessagesReceivedTotal               *prometheus.CounterVec
+	RouterAdvertisementsTotal           *prometheus.CounterVec
+	RouterSolicitationsCoalescedTotal   *prometheus.CounterVec
+	RouterSolicitationsRateLimitedTotal *prometheus.CounterVec
+	ErrorsTotal                         *prometheus.CounterVec
+	SchedulerWorkers                    *prometheus.GaugeVec
+	DeprecatedPrefixes                  *prometheus.GaugeVec
+	FinalRouterAdvertisements           *prometheus.CounterVec
+	RouterAdvertisementSplitsTotal      *prometheus.CounterVec
+	RouterAdvertisementChangesTotal     *prometheus.CounterVec
+	InterfaceState                      *prometheus.GaugeVec
 }
 
 // NewAdvertiserMetrics creates and registers AdvertiserMetrics. If reg is nil
@@ -50,8 +57,8 @@ func NewAdvertiserMetrics(reg *prometheus.Registry) *AdvertiserMetrics {
 			Subsystem: subsystem,
 			Name:      "messages_received_total",
 
-			Help: "The total number of NDP messages received on a listening interface.",
-		}, []string{"interface", "message"}),
+			Help: "The total number of NDP messages received on a listening interface, and the reason invalid messages were dropped, if any.",
+		}, []string{"interface", "message", "reason"}),
 
 		RouterAdvertisementsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: namespace,
@@ -61,6 +68,30 @@ func NewAdvertiserMetrics(reg *prometheus.Registry) *AdvertiserMetrics {
 			Help: "The total number of NDP router advertisements sent by the advertiser on an interface.",
 		}, []string{"interface", "type"}),
 
+		RouterSolicitationsCoalescedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "router_solicitations_coalesced_total",
+
+			Help: "The total number of NDP router solicitations which were answered by a multicast router advertisement rather than a unicast one.",
+		}, names),
+
+		RouterSolicitationsRateLimitedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "router_solicitations_rate_limited_total",
+
+			Help: "The total number of NDP router solicitations which were dropped due to exceeding a per-host or global rate limit.",
+		}, []string{"interface", "limit"}),
+
+		InterfaceState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "interface_state",
+
+			Help: "Indicates the lifecycle state of the advertiser on an interface: waiting for the interface, running, or stopped.",
+		}, []string{"interface", "state"}),
+
 		ErrorsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: namespace,
 			Subsystem: subsystem,
@@ -76,6 +107,38 @@ func NewAdvertiserMetrics(reg *prometheus.Registry) *AdvertiserMetrics {
 
 			Help: "The number of router advertisement scheduler worker goroutines that are running.",
 		}, names),
+
+		DeprecatedPrefixes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "deprecated_prefixes",
+
+			Help: "The number of prefixes which disappeared from an interface and are being advertised as deprecated.",
+		}, names),
+
+		FinalRouterAdvertisements: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "final_router_advertisements_total",
+
+			Help: "The total number of final NDP router advertisements sent by the advertiser on an interface while shutting down.",
+		}, names),
+
+		RouterAdvertisementSplitsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "router_advertisement_splits_total",
+
+			Help: "The total number of NDP router advertisements which exceeded the interface MTU and were split into multiple packets.",
+		}, names),
+
+		RouterAdvertisementChangesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "router_advertisement_changes_total",
+
+			Help: "The total number of times the NDP router advertisement for an interface changed, causing an immediate multicast router advertisement.",
+		}, names),
 	}
 
 	if reg != nil {
@@ -83,24 +146,52 @@ func NewAdvertiserMetrics(reg *prometheus.Registry) *AdvertiserMetrics {
 			mm.LastMulticastTime,
 			mm.MessagesReceivedTotal,
 			mm.RouterAdvertisementsTotal,
+			mm.RouterSolicitationsCoalescedTotal,
+			mm.RouterSolicitationsRateLimitedTotal,
+			mm.ErrorsTotal,
 			mm.SchedulerWorkers,
+			mm.DeprecatedPrefixes,
+			mm.FinalRouterAdvertisements,
+			mm.RouterAdvertisementSplitsTotal,
+			mm.RouterAdvertisementChangesTotal,
+			mm.InterfaceState,
 		)
 	}
 
 	return mm
 }
 
+// setState sets the lifecycle state of an interface, clearing any other
+// states.
+func (mm *AdvertiserMetrics) setState(iface, state string) {
+	for _, s := range []string{stateWaiting, stateRunning, stateStopped} {
+		var v float64
+		if s == state {
+			v = 1
+		}
+
+		mm.InterfaceState.WithLabelValues(iface, s).Set(v)
+	}
+}
+
 // An interfaceCollector collects Prometheus metrics for a network interface.
 type interfaceCollector struct {
 	Autoconfiguration  *prometheus.Desc
 	Forwarding         *prometheus.Desc
 	SendAdvertisements *prometheus.Desc
 
+	// Reported only for interfaces which manage these sysctls.
+	AcceptRA       *prometheus.Desc
+	AcceptRADefrtr *prometheus.Desc
+	MTU            *prometheus.Desc
+
 	ifis []config.Interface
+	fc   *forwardingCache
 }
 
-// newInterfaceCollector creates an interfaceCollector.
-func newInterfaceCollector(ifis []config.Interface) prometheus.Collector {
+// newInterfaceCollector creates an interfaceCollector which reports the
+// forwarding state cached in fc for interfaces with a running Advertiser.
+func newInterfaceCollector(ifis []config.Interface, fc *forwardingCache) prometheus.Collector {
 	const subsystem = "interface"
 
 	labels := []string{"interface"}
@@ -115,7 +206,7 @@ func newInterfaceCollector(ifis []config.Interface) prometheus.Collector {
 
 		Forwarding: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, subsystem, "forwarding"),
-			"Indicates whether or not IPv6 forwarding is enabled on this interface.",
+			"Indicates whether or not IPv6 forwarding is enabled on this interface, including when forwarding is managed by the interface's sysctl configuration.",
 			labels,
 			nil,
 		),
@@ -127,7 +218,29 @@ func newInterfaceCollector(ifis []config.Interface) prometheus.Collector {
 			nil,
 		),
 
+		AcceptRA: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "accept_ra"),
+			"The value of the managed IPv6 accept_ra sysctl for this interface.",
+			labels,
+			nil,
+		),
+
+		AcceptRADefrtr: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "accept_ra_defrtr"),
+			"The value of the managed IPv6 accept_ra_defrtr sysctl for this interface.",
+			labels,
+			nil,
+		),
+
+		MTU: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "ipv6_mtu_bytes"),
+			"The value of the managed IPv6 mtu sysctl for this interface.",
+			labels,
+			nil,
+		),
+
 		ifis: ifis,
+		fc:   fc,
 	}
 }
 
@@ -137,6 +250,9 @@ func (c *interfaceCollector) Describe(ch chan<- *prometheus.Desc) {
 		c.Autoconfiguration,
 		c.Forwarding,
 		c.SendAdvertisements,
+		c.AcceptRA,
+		c.AcceptRADefrtr,
+		c.MTU,
 	}
 
 	for _, d := range ds {
@@ -148,16 +264,22 @@ func (c *interfaceCollector) Describe(ch chan<- *prometheus.Desc) {
 // Collect implements prometheus.Collector.
 func (c *interfaceCollector) Collect(ch chan<- prometheus.Metric) {
 	for _, ifi := range c.ifis {
+		// Interfaces may not exist yet, so report errors but continue to
+		// collect metrics for other interfaces.
 		auto, err := getIPv6Autoconf(ifi.Name)
 		if err != nil {
 			ch <- prometheus.NewInvalidMetric(c.Autoconfiguration, err)
-			return
+			continue
 		}
 
-		fwd, err := getIPv6Forwarding(ifi.Name)
-		if err != nil {
-			ch <- prometheus.NewInvalidMetric(c.Forwarding, err)
-			return
+		// Prefer the state tracked by a running Advertiser, if any.
+		fwd, ok := c.fc.Get(ifi.Name)
+		if !ok {
+			fwd, err = getIPv6Forwarding(ifi.Name)
+			if err != nil {
+				ch <- prometheus.NewInvalidMetric(c.Forwarding, err)
+				continue
+			}
 		}
 
 		ch <- prometheus.MustNewConstMetric(
@@ -181,6 +303,35 @@ func (c *interfaceCollector) Collect(ch chan<- prometheus.Metric) {
 			ifi.Name,
 		)
 
+		// Report the current values of managed sysctls. A managed forwarding
+		// sysctl is not reported again, because the forwarding metric above
+		// always reports its current value.
+		for _, s := range managedSysctls(ifi.Sysctls) {
+			var d *prometheus.Desc
+			switch s.Key {
+			case sysctlAcceptRA:
+				d = c.AcceptRA
+			case sysctlAcceptRADefrtr:
+				d = c.AcceptRADefrtr
+			case sysctlMTU:
+				d = c.MTU
+			default:
+				continue
+			}
+
+			v, err := getSysctl(ifi.Name, s.Key)
+			if err != nil {
+				ch <- prometheus.NewInvalidMetric(d, err)
+				continue
+			}
+
+			ch <- prometheus.MustNewConstMetric(
+				d,
+				prometheus.GaugeValue,
+				float64(v),
+				ifi.Name,
+			)
+		}
 	}
 }
 
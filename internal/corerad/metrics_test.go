@@ -14,6 +14,7 @@
 package corerad
 
 import (
+	"fmt"
 	"net"
 	"testing"
 
@@ -32,6 +33,7 @@ func Test_interfaceCollector(t *testing.T) {
 	tests := []struct {
 		name    string
 		ifis    []config.Interface
+		fwd     map[string]bool
 		metrics []string
 	}{
 		{
@@ -46,11 +48,46 @@ func Test_interfaceCollector(t *testing.T) {
 				`corerad_interface_send_advertisements{interface="lo"} 1`,
 			},
 		},
+		{
+			name: "managed sysctls",
+			ifis: []config.Interface{{
+				Name: loop.Name,
+				Sysctls: config.Sysctls{
+					AcceptRA: func() *int { v := 0; return &v }(),
+					MTU:      func() *int { v := 1280; return &v }(),
+				},
+			}},
+			metrics: []string{
+				`corerad_interface_autoconfiguration{interface="lo"} 1`,
+				`corerad_interface_forwarding{interface="lo"} 0`,
+				`corerad_interface_send_advertisements{interface="lo"} 0`,
+				fmt.Sprintf(`corerad_interface_accept_ra{interface="lo"} %d`, mustGetSysctl(t, loop.Name, sysctlAcceptRA)),
+				fmt.Sprintf(`corerad_interface_ipv6_mtu_bytes{interface="lo"} %d`, mustGetSysctl(t, loop.Name, sysctlMTU)),
+			},
+		},
+		{
+			name: "cached forwarding",
+			ifis: []config.Interface{{
+				Name:               loop.Name,
+				SendAdvertisements: true,
+			}},
+			fwd: map[string]bool{loop.Name: true},
+			metrics: []string{
+				`corerad_interface_autoconfiguration{interface="lo"} 1`,
+				`corerad_interface_forwarding{interface="lo"} 1`,
+				`corerad_interface_send_advertisements{interface="lo"} 1`,
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			body := promtest.Collect(t, newInterfaceCollector(tt.ifis))
+			fc := newForwardingCache()
+			for iface, fwd := range tt.fwd {
+				fc.Set(iface, fwd)
+			}
+
+			body := promtest.Collect(t, newInterfaceCollector(tt.ifis, fc))
 
 			if !promtest.Lint(t, body) {
 				t.Fatal("one or more promlint errors found")
@@ -62,3 +99,14 @@ func Test_interfaceCollector(t *testing.T) {
 		})
 	}
 }
+
+func mustGetSysctl(t *testing.T, iface, key string) int {
+	t.Helper()
+
+	v, err := getSysctl(iface, key)
+	if err != nil {
+		t.Fatalf("failed to get sysctl %s/%s: %v", iface, key, err)
+	}
+
+	return v
+}
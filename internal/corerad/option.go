@@ -0,0 +1,362 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/mdlayher/corerad/internal/config"
+	"github.com/mdlayher/ndp"
+)
+
+// This file contains NDP options which are not supported by package ndp, and
+// are instead marshaled into ndp.RawOptions.
+
+// Type values for NDP options marshaled by CoreRAD.
+const (
+	optPrefixInformation     = 3
+	optAdvertisementInterval = 7
+	optHomeAgentInformation  = 8
+	optRouteInformation      = 24
+	optCaptivePortal         = 37
+	optPREF64                = 38
+	optDNR                   = 144
+)
+
+// A routerAddressOption is a Prefix Information option with the Router
+// Address flag set, produced by routerAddressPrefix.
+//
+// Options built by CoreRAD which carry lifetimes are wrapped in distinct types
+// so their lifetimes can be modified, while raw options configured by the
+// user are never inspected or modified, whatever their type.
+type routerAddressOption struct{ *ndp.RawOption }
+
+// A routeInformationOption is a Route Information option, produced by
+// routeInformation.
+type routeInformationOption struct{ *ndp.RawOption }
+
+// rawOption produces an ndp.RawOption with the specified type and value,
+// padding the value with zeros to fill the option's final 8 octet unit.
+func rawOption(typ uint8, value []byte) (*ndp.RawOption, error) {
+	// The type and length fields occupy the first two octets of the option,
+	// and the length is specified in units of 8 octets.
+	l := (2 + len(value) + 7) / 8
+	if l > 255 {
+		return nil, fmt.Errorf("option type %d value of %d bytes is too long", typ, len(value))
+	}
+
+	b := make([]byte, l*8-2)
+	copy(b, value)
+
+	return &ndp.RawOption{
+		Type:   typ,
+		Length: uint8(l),
+		Value:  b,
+	}, nil
+}
+
+// advertisementInterval produces an Advertisement Interval option, as
+// described in RFC 6275, section 7.3.
+func advertisementInterval(interval time.Duration) (*ndp.RawOption, error) {
+	// Two reserved octets precede the interval in milliseconds.
+	b := make([]byte, 6)
+	binary.BigEndian.PutUint32(b[2:6], uint32(interval/time.Millisecond))
+
+	return rawOption(optAdvertisementInterval, b)
+}
+
+// homeAgentInformation produces a Home Agent Information option, as described
+// in RFC 6275, section 7.4.
+func homeAgentInformation(preference int16, lifetime time.Duration) (*ndp.RawOption, error) {
+	// Two reserved octets precede the preference and lifetime in seconds.
+	b := make([]byte, 6)
+	binary.BigEndian.PutUint16(b[2:4], uint16(preference))
+	binary.BigEndian.PutUint16(b[4:6], uint16(lifetime.Seconds()))
+
+	return rawOption(optHomeAgentInformation, b)
+}
+
+// routerAddressPrefix produces a Prefix Information option with the Router
+// Address flag set, as described in RFC 6275, section 7.2. Unlike
+// ndp.PrefixInformation, the Prefix field carries the router's full address.
+func routerAddressPrefix(pi *ndp.PrefixInformation, addr net.IP) (*routerAddressOption, error) {
+	b := make([]byte, 30)
+	b[0] = pi.PrefixLength
+	if pi.OnLink {
+		b[1] |= 1 << 7
+	}
+	if pi.AutonomousAddressConfiguration {
+		b[1] |= 1 << 6
+	}
+	b[1] |= 1 << 5
+
+	binary.BigEndian.PutUint32(b[2:6], uint32(pi.ValidLifetime.Seconds()))
+	binary.BigEndian.PutUint32(b[6:10], uint32(pi.PreferredLifetime.Seconds()))
+
+	// 4 bytes reserved.
+
+	copy(b[14:30], addr.To16())
+
+	raw, err := rawOption(optPrefixInformation, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return &routerAddressOption{raw}, nil
+}
+
+// routeInformation produces a Route Information option, as described in
+// RFC 4191, section 2.3.
+func routeInformation(
+	prefix net.IP,
+	length int,
+	preference ndp.RouterSelectionPreference,
+	lifetime time.Duration,
+) (*routeInformationOption, error) {
+	// Only the significant octets of the prefix are included, so the option
+	// may be 1, 2, or 3 units of 8 octets.
+	var n int
+	switch {
+	case length == 0:
+		n = 0
+	case length <= 64:
+		n = 8
+	default:
+		n = 16
+	}
+
+	b := make([]byte, 6+n)
+	b[0] = uint8(length)
+	b[1] = uint8(preference) << 3
+	binary.BigEndian.PutUint32(b[2:6], uint32(lifetime.Seconds()))
+	copy(b[6:], prefix.Mask(net.CIDRMask(length, 128))[:n])
+
+	raw, err := rawOption(optRouteInformation, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return &routeInformationOption{raw}, nil
+}
+
+// captivePortal produces a Captive-Portal option, as described in RFC 8910,
+// section 2.3.
+func captivePortal(uri string) (*ndp.RawOption, error) {
+	// The URI is padded with NUL bytes to the end of the option.
+	return rawOption(optCaptivePortal, []byte(uri))
+}
+
+// pref64 produces a PREF64 option, as described in RFC 8781, section 4.
+func pref64(prefix *net.IPNet, lifetime time.Duration) (*ndp.RawOption, error) {
+	// The Prefix Length Code identifies the length of the NAT64 prefix.
+	var plc uint16
+	switch length, _ := prefix.Mask.Size(); length {
+	case 96:
+		plc = 0
+	case 64:
+		plc = 1
+	case 56:
+		plc = 2
+	case 48:
+		plc = 3
+	case 40:
+		plc = 4
+	case 32:
+		plc = 5
+	default:
+		return nil, fmt.Errorf("invalid PREF64 prefix length: %d", length)
+	}
+
+	// The lifetime is specified in units of 8 seconds and must be rounded up
+	// so hosts do not expire the prefix before the next router advertisement.
+	scaled := (lifetime + 8*time.Second - 1) / (8 * time.Second)
+	if scaled > 8191 {
+		return nil, fmt.Errorf("PREF64 lifetime of %s is too long", lifetime)
+	}
+
+	b := make([]byte, 14)
+	binary.BigEndian.PutUint16(b[0:2], uint16(scaled)<<3|plc)
+
+	// Only the highest 96 bits of the prefix are included.
+	copy(b[2:14], prefix.IP.To16()[:12])
+
+	return rawOption(optPREF64, b)
+}
+
+// SvcParamKey values used in the SvcParams of a DNR option, as described in
+// RFC 9460, section 14.3.2.
+const (
+	svcParamALPN    = 1
+	svcParamPort    = 3
+	svcParamDoHPath = 7
+)
+
+// dnr produces an Encrypted DNS option, as described in RFC 9463, section 6.1.
+func dnr(d *config.DNR) (*ndp.RawOption, error) {
+	adn := domainName(d.AuthenticationDomainName)
+
+	addrs := make([]byte, 0, 16*len(d.Servers))
+	for _, s := range d.Servers {
+		ip := s.To16()
+		if ip == nil || ip.To4() != nil {
+			return nil, fmt.Errorf("invalid DNR IPv6 address: %s", s)
+		}
+
+		addrs = append(addrs, ip...)
+	}
+
+	// SvcParams must appear in strictly increasing order by key.
+	var alpn []byte
+	for _, a := range d.ALPN {
+		alpn = append(alpn, uint8(len(a)))
+		alpn = append(alpn, a...)
+	}
+
+	params := svcParam(nil, svcParamALPN, alpn)
+	if d.Port != 0 {
+		var port [2]byte
+		binary.BigEndian.PutUint16(port[:], d.Port)
+		params = svcParam(params, svcParamPort, port[:])
+	}
+	if d.DoHPath != "" {
+		params = svcParam(params, svcParamDoHPath, []byte(d.DoHPath))
+	}
+
+	b := make([]byte, 6, 6+2+len(adn)+2+len(addrs)+2+len(params))
+	binary.BigEndian.PutUint16(b[0:2], d.ServicePriority)
+	binary.BigEndian.PutUint32(b[2:6], uint32(d.Lifetime.Seconds()))
+
+	for _, f := range [][]byte{adn, addrs, params} {
+		b = append(b, 0, 0)
+		binary.BigEndian.PutUint16(b[len(b)-2:], uint16(len(f)))
+		b = append(b, f...)
+	}
+
+	return rawOption(optDNR, b)
+}
+
+// svcParam appends a single SvcParam with the specified key and value to b.
+func svcParam(b []byte, key uint16, value []byte) []byte {
+	var kl [4]byte
+	binary.BigEndian.PutUint16(kl[0:2], key)
+	binary.BigEndian.PutUint16(kl[2:4], uint16(len(value)))
+
+	b = append(b, kl[:]...)
+	return append(b, value...)
+}
+
+// domainName encodes a validated domain name in DNS wire format, as described
+// in RFC 1035, section 3.1.
+func domainName(s string) []byte {
+	var b []byte
+	for _, l := range strings.Split(strings.TrimSuffix(s, "."), ".") {
+		b = append(b, uint8(len(l)))
+		b = append(b, l...)
+	}
+
+	return append(b, 0)
+}
+
+// deprecate modifies the options of ra in place so that hosts stop using
+// the configuration it carries: prefixes are deprecated, and routes, RDNSS
+// servers, and DNSSL domains expire immediately. If valid is non-zero, prefix
+// valid lifetimes are reduced to at most valid.
+func deprecate(ra *ndp.RouterAdvertisement, valid time.Duration) {
+	for _, o := range ra.Options {
+		switch o := o.(type) {
+		case *ndp.PrefixInformation:
+			o.PreferredLifetime = 0
+			if valid != 0 && o.ValidLifetime > valid {
+				o.ValidLifetime = valid
+			}
+		case *routerAddressOption:
+			binary.BigEndian.PutUint32(o.Value[6:10], 0)
+			if v := uint32(valid.Seconds()); valid != 0 && binary.BigEndian.Uint32(o.Value[2:6]) > v {
+				binary.BigEndian.PutUint32(o.Value[2:6], v)
+			}
+		case *routeInformationOption:
+			binary.BigEndian.PutUint32(o.Value[2:6], 0)
+		case *ndp.RecursiveDNSServer:
+			o.Lifetime = 0
+		case *ndp.DNSSearchList:
+			o.Lifetime = 0
+		}
+	}
+}
+
+// normalizeLifetimes returns a copy of ra in which each non-zero lifetime is
+// replaced with a fixed value, so that advertisements may be compared while
+// ignoring lifetimes which decrement in real time. Lifetimes of zero are kept
+// so that deprecation is still detected.
+func normalizeLifetimes(ra *ndp.RouterAdvertisement) *ndp.RouterAdvertisement {
+	const fixed = time.Second
+
+	norm := func(d time.Duration) time.Duration {
+		if d == 0 {
+			return 0
+		}
+		return fixed
+	}
+
+	normRaw := func(b []byte) {
+		if binary.BigEndian.Uint32(b) != 0 {
+			binary.BigEndian.PutUint32(b, uint32(fixed.Seconds()))
+		}
+	}
+
+	out := *ra
+	out.Options = make([]ndp.Option, 0, len(ra.Options))
+
+	for _, o := range ra.Options {
+		switch o := o.(type) {
+		case *ndp.PrefixInformation:
+			pi := *o
+			pi.PreferredLifetime = norm(pi.PreferredLifetime)
+			pi.ValidLifetime = norm(pi.ValidLifetime)
+			out.Options = append(out.Options, &pi)
+		case *routerAddressOption:
+			raw := *o.RawOption
+			raw.Value = append([]byte(nil), o.Value...)
+			normRaw(raw.Value[2:6])
+			normRaw(raw.Value[6:10])
+			out.Options = append(out.Options, &routerAddressOption{&raw})
+		case *routeInformationOption:
+			raw := *o.RawOption
+			raw.Value = append([]byte(nil), o.Value...)
+			normRaw(raw.Value[2:6])
+			out.Options = append(out.Options, &routeInformationOption{&raw})
+		case *ndp.RawOption:
+			// Raw options configured by the user are compared as-is.
+			raw := *o
+			raw.Value = append([]byte(nil), o.Value...)
+			out.Options = append(out.Options, &raw)
+		case *ndp.RecursiveDNSServer:
+			rdnss := *o
+			rdnss.Lifetime = norm(rdnss.Lifetime)
+			out.Options = append(out.Options, &rdnss)
+		case *ndp.DNSSearchList:
+			dnssl := *o
+			dnssl.Lifetime = norm(dnssl.Lifetime)
+			out.Options = append(out.Options, &dnssl)
+		default:
+			out.Options = append(out.Options, o)
+		}
+	}
+
+	return &out
+}
@@ -0,0 +1,145 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import (
+	"container/list"
+	"net"
+	"time"
+)
+
+// maxRateLimitSources is the maximum number of soliciting hosts tracked by a
+// rateLimiter before the least recently seen hosts are evicted.
+const maxRateLimitSources = 1024
+
+// Limits which may cause a rateLimiter to drop a router solicitation.
+const (
+	limitSource = "source"
+	limitGlobal = "global"
+)
+
+// A rateLimiter applies token bucket rate limits to router solicitations,
+// both per soliciting host and for all hosts. It is not safe for concurrent
+// use.
+type rateLimiter struct {
+	interval, globalInterval time.Duration
+	burst, globalBurst       int
+	size                     int
+
+	global  tokenBucket
+	ll      *list.List
+	sources map[rateKey]*list.Element
+}
+
+// A rateKey identifies a soliciting host.
+type rateKey struct {
+	ip, addr string
+}
+
+// A rateEntry is a tokenBucket for a single host in a rateLimiter.
+type rateEntry struct {
+	key rateKey
+	b   tokenBucket
+}
+
+// newRateLimiter creates a rateLimiter which tracks up to size hosts. Each
+// host may send up to burst solicitations at once, and earns another every
+// interval. All hosts may send one solicitation every globalInterval, with a
+// burst of one second's worth. Zero intervals disable their limits.
+func newRateLimiter(interval time.Duration, burst int, globalInterval time.Duration, size int) *rateLimiter {
+	globalBurst := 1
+	if globalInterval > 0 && globalInterval < time.Second {
+		globalBurst = int(time.Second / globalInterval)
+	}
+
+	return &rateLimiter{
+		interval:       interval,
+		globalInterval: globalInterval,
+		burst:          burst,
+		globalBurst:    globalBurst,
+		size:           size,
+
+		ll:      list.New(),
+		sources: make(map[rateKey]*list.Element),
+	}
+}
+
+// Allow determines whether a solicitation from the host with IP address ip
+// and link-layer address addr at time now is permitted. If not, it returns
+// the limit which caused the solicitation to be dropped.
+func (rl *rateLimiter) Allow(now time.Time, ip net.IP, addr net.HardwareAddr) (bool, string) {
+	if rl.interval > 0 && !rl.source(ip, addr).Take(now, rl.interval, rl.burst) {
+		return false, limitSource
+	}
+
+	if rl.globalInterval > 0 && !rl.global.Take(now, rl.globalInterval, rl.globalBurst) {
+		return false, limitGlobal
+	}
+
+	return true, ""
+}
+
+// source returns the tokenBucket for a host, evicting the least recently
+// seen host if necessary.
+func (rl *rateLimiter) source(ip net.IP, addr net.HardwareAddr) *tokenBucket {
+	key := rateKey{ip: ip.String(), addr: addr.String()}
+	if e, ok := rl.sources[key]; ok {
+		rl.ll.MoveToFront(e)
+		return &e.Value.(*rateEntry).b
+	}
+
+	if rl.ll.Len() >= rl.size {
+		e := rl.ll.Back()
+		rl.ll.Remove(e)
+		delete(rl.sources, e.Value.(*rateEntry).key)
+	}
+
+	re := &rateEntry{key: key}
+	rl.sources[key] = rl.ll.PushFront(re)
+	return &re.b
+}
+
+// Len returns the number of hosts tracked by the rateLimiter.
+func (rl *rateLimiter) Len() int { return rl.ll.Len() }
+
+// A tokenBucket is a token bucket rate limiter. The zero value is a full
+// bucket.
+type tokenBucket struct {
+	used   bool
+	tokens float64
+	last   time.Time
+}
+
+// Take refills the bucket with one token for every interval elapsed since
+// the last call, up to burst tokens, and then takes a token if one is
+// available.
+func (b *tokenBucket) Take(now time.Time, interval time.Duration, burst int) bool {
+	if !b.used {
+		b.used = true
+		b.tokens = float64(burst)
+	} else if d := now.Sub(b.last); d > 0 {
+		b.tokens += float64(d) / float64(interval)
+		if b.tokens > float64(burst) {
+			b.tokens = float64(burst)
+		}
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
@@ -0,0 +1,133 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_rateLimiter(t *testing.T) {
+	var (
+		start = time.Unix(1000, 0)
+
+		ip0  = mustIP("fe80::1")
+		ip1  = mustIP("fe80::2")
+		mac0 = net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+		mac1 = net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xae}
+	)
+
+	type solicit struct {
+		at    time.Duration
+		ip    net.IP
+		mac   net.HardwareAddr
+		limit string
+	}
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		burst    int
+		global   time.Duration
+		size     int
+		rs       []solicit
+	}{
+		{
+			name: "disabled",
+			size: 1,
+			rs: []solicit{
+				{ip: ip0, mac: mac0},
+				{ip: ip0, mac: mac0},
+				{ip: ip0, mac: mac0},
+			},
+		},
+		{
+			name:     "source burst and refill",
+			interval: time.Second,
+			burst:    2,
+			size:     1,
+			rs: []solicit{
+				{ip: ip0, mac: mac0},
+				{ip: ip0, mac: mac0},
+				{ip: ip0, mac: mac0, limit: limitSource},
+				{at: 500 * time.Millisecond, ip: ip0, mac: mac0, limit: limitSource},
+				{at: time.Second, ip: ip0, mac: mac0},
+				{at: time.Second, ip: ip0, mac: mac0, limit: limitSource},
+				// Refilled, but only up to the burst size.
+				{at: time.Hour, ip: ip0, mac: mac0},
+				{at: time.Hour, ip: ip0, mac: mac0},
+				{at: time.Hour, ip: ip0, mac: mac0, limit: limitSource},
+			},
+		},
+		{
+			name:     "source by IP and link-layer address",
+			interval: time.Second,
+			burst:    1,
+			size:     3,
+			rs: []solicit{
+				{ip: ip0, mac: mac0},
+				{ip: ip0, mac: mac1},
+				{ip: ip1, mac: mac0},
+				{ip: ip0, mac: mac0, limit: limitSource},
+			},
+		},
+		{
+			name:     "source evicted",
+			interval: time.Second,
+			burst:    1,
+			size:     1,
+			rs: []solicit{
+				{ip: ip0, mac: mac0},
+				{ip: ip0, mac: mac0, limit: limitSource},
+				// Evicts ip0, so it is treated as a new host.
+				{ip: ip1, mac: mac0},
+				{ip: ip0, mac: mac0},
+			},
+		},
+		{
+			name:   "global",
+			global: 500 * time.Millisecond,
+			size:   1,
+			rs: []solicit{
+				{ip: ip0, mac: mac0},
+				{ip: ip1, mac: mac1},
+				{ip: ip0, mac: mac1, limit: limitGlobal},
+				{at: 500 * time.Millisecond, ip: ip1, mac: mac0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := newRateLimiter(tt.interval, tt.burst, tt.global, tt.size)
+
+			for i, rs := range tt.rs {
+				ok, limit := rl.Allow(start.Add(rs.at), rs.ip, rs.mac)
+				if diff := cmp.Diff(rs.limit == "", ok); diff != "" {
+					t.Fatalf("unexpected allow for RS %d (-want +got):\n%s", i, diff)
+				}
+				if diff := cmp.Diff(rs.limit, limit); diff != "" {
+					t.Fatalf("unexpected limit for RS %d (-want +got):\n%s", i, diff)
+				}
+			}
+
+			if rl.Len() > tt.size {
+				t.Fatalf("rate limiter tracks %d hosts, exceeding size %d", rl.Len(), tt.size)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// A resolvConf is the subset of a resolv.conf file which is relevant to
+// router advertisements.
+type resolvConf struct {
+	Nameservers []net.IP
+	Search      []string
+}
+
+// parseResolvConf parses IPv6 nameservers and search domains from a
+// resolv.conf file, as described in resolv.conf(5).
+func parseResolvConf(r io.Reader) (*resolvConf, error) {
+	var rc resolvConf
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.IndexAny(line, "#;"); i != -1 {
+			line = line[:i]
+		}
+
+		fs := strings.Fields(line)
+		if len(fs) < 2 {
+			continue
+		}
+
+		switch fs[0] {
+		case "nameserver":
+			// Only IPv6 nameservers which are reachable by other hosts on
+			// the link can be advertised. Zoned and link-local addresses
+			// refer to a link on this host which may not be the link an RA
+			// is sent on, so they are skipped.
+			ip := net.ParseIP(fs[1])
+			if ip == nil || ip.To4() != nil || ip.IsLoopback() ||
+				ip.IsUnspecified() || ip.IsLinkLocalUnicast() {
+				continue
+			}
+
+			rc.Nameservers = append(rc.Nameservers, ip)
+		case "domain", "search":
+			// The last domain or search line takes precedence.
+			rc.Search = rc.Search[:0]
+			for _, d := range fs[1:] {
+				if d = strings.TrimSuffix(d, "."); d != "" {
+					rc.Search = append(rc.Search, d)
+				}
+			}
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return &rc, nil
+}
+
+// resolvConfFile produces a function which returns the contents of a
+// resolv.conf file, and reports whether they changed since the previous call.
+// Files are only parsed again when they are modified. If the file cannot be
+// read, the last contents which were read successfully are returned along
+// with the error.
+func resolvConfFile() func(path string) (*resolvConf, bool, error) {
+	c := newFileCache(func(r io.Reader, _ time.Time) (interface{}, error) {
+		return parseResolvConf(r)
+	})
+
+	return func(path string) (*resolvConf, bool, error) {
+		v, changed, err := c.Get(path)
+		if err != nil {
+			// Hand back the last good contents, if any, with the error.
+			rc, _ := v.(*resolvConf)
+			return rc, false, err
+		}
+
+		return v.(*resolvConf), changed, nil
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_parseResolvConf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		s    string
+		rc   *resolvConf
+	}{
+		{
+			name: "empty",
+			rc:   &resolvConf{},
+		},
+		{
+			name: "OK",
+			s: `
+# Generated by a DHCP client.
+nameserver 192.0.2.1
+nameserver 2001:db8::1 ; trailing comment
+nameserver fe80::1%eth0
+nameserver fe80::2
+nameserver 2001:db8::2%eth0
+nameserver ::1
+nameserver foo
+domain example.com
+search foo.example.com. bar.example.com
+options edns0
+`,
+			rc: &resolvConf{
+				Nameservers: []net.IP{
+					mustIP("2001:db8::1"),
+				},
+				Search: []string{"foo.example.com", "bar.example.com"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc, err := parseResolvConf(strings.NewReader(tt.s))
+			if err != nil {
+				t.Fatalf("failed to parse resolv.conf: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.rc, rc); diff != "" {
+				t.Fatalf("unexpected resolv.conf (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_resolvConfFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "corerad-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		path = filepath.Join(dir, "resolv.conf")
+		fn   = resolvConfFile()
+	)
+
+	if _, _, err := fn(path); !os.IsNotExist(err) {
+		t.Fatalf("expected does not exist error, but got: %v", err)
+	}
+
+	write := func(s string) {
+		t.Helper()
+
+		if err := ioutil.WriteFile(path, []byte(s), 0o644); err != nil {
+			t.Fatalf("failed to write resolv.conf: %v", err)
+		}
+	}
+
+	get := func(want *resolvConf, wantChanged bool) {
+		t.Helper()
+
+		rc, changed, err := fn(path)
+		if err != nil {
+			t.Fatalf("failed to get resolv.conf: %v", err)
+		}
+
+		if diff := cmp.Diff(want, rc); diff != "" {
+			t.Fatalf("unexpected resolv.conf (-want +got):\n%s", diff)
+		}
+		if changed != wantChanged {
+			t.Fatalf("unexpected changed value: %v", changed)
+		}
+	}
+
+	write("nameserver 2001:db8::1\n")
+
+	one := &resolvConf{Nameservers: []net.IP{mustIP("2001:db8::1")}}
+	get(one, true)
+	get(one, false)
+
+	// The size of the file changes, so it must be parsed again.
+	write("nameserver 2001:db8::2\nsearch example.com\n")
+	two := &resolvConf{
+		Nameservers: []net.IP{mustIP("2001:db8::2")},
+		Search:      []string{"example.com"},
+	}
+	get(two, true)
+
+	// The file is removed, so the last good contents are returned along with
+	// the error.
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("failed to remove resolv.conf: %v", err)
+	}
+
+	rc, changed, err := fn(path)
+	if !os.IsNotExist(err) {
+		t.Fatalf("expected does not exist error, but got: %v", err)
+	}
+	if diff := cmp.Diff(two, rc); diff != "" {
+		t.Fatalf("unexpected resolv.conf (-want +got):\n%s", diff)
+	}
+	if changed {
+		t.Fatal("resolv.conf should not be reported as changed")
+	}
+}
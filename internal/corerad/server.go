@@ -15,6 +15,7 @@ package corerad
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -38,6 +39,7 @@ type Server struct {
 
 	ll  *log.Logger
 	reg *prometheus.Registry
+	fc  *forwardingCache
 
 	eg    *errgroup.Group
 	ready chan struct{}
@@ -50,12 +52,16 @@ func NewServer(cfg config.Config, ll *log.Logger) *Server {
 		ll = log.New(ioutil.Discard, "", 0)
 	}
 
+	// Advertisers track the IPv6 forwarding state of their interfaces, which
+	// is also reported in metrics.
+	fc := newForwardingCache()
+
 	// Set up Prometheus instrumentation using the typical Go collectors.
 	reg := prometheus.NewPedanticRegistry()
 	reg.MustRegister(
 		prometheus.NewGoCollector(),
 		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
-		newInterfaceCollector(cfg.Interfaces),
+		newInterfaceCollector(cfg.Interfaces, fc),
 	)
 
 	return &Server{
@@ -63,6 +69,7 @@ func NewServer(cfg config.Config, ll *log.Logger) *Server {
 
 		ll:  ll,
 		reg: reg,
+		fc:  fc,
 
 		ready: make(chan struct{}),
 	}
@@ -99,11 +106,40 @@ func (s *Server) Run(ctx context.Context) error {
 			logf("plugin %02d: %q: %s", i, p.Name(), p)
 		}
 
+		if ifi.WaitForInterface {
+			// Advertise whenever this interface is available until the
+			// context is canceled.
+			ifi := ifi
+			s.eg.Go(func() error {
+				return superviseAdvertiser(ctx, ifi, s.ll, mm, s.fc)
+			})
+			continue
+		}
+
 		// TODO: find a way to reasonably test this.
 
 		// Begin advertising on this interface until the context is canceled.
-		ad, err := NewAdvertiser(ifi, s.ll, mm)
-		if err != nil {
+		ad, err := newAdvertiser(ctx, ifi, s.ll, mm, s.fc)
+		switch {
+		case err != nil && ctx.Err() != nil:
+			// Canceled while waiting for the interface, so wait for any
+			// advertisers which have already started to stop.
+			if err := s.eg.Wait(); err != nil {
+				return fmt.Errorf("failed to serve: %v", err)
+			}
+
+			return nil
+		case errors.Is(err, errDADFailed):
+			// Don't exit due to an address conflict on the link. Instead,
+			// keep trying in case a usable address is assigned later.
+			logf("%v, will retry", err)
+
+			ifi := ifi
+			s.eg.Go(func() error {
+				return superviseAdvertiser(ctx, ifi, s.ll, mm, s.fc)
+			})
+			continue
+		case err != nil:
 			return fmt.Errorf("failed to create NDP advertiser: %v", err)
 		}
 
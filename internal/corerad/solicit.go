@@ -0,0 +1,175 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/mdlayher/ndp"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv6"
+)
+
+// A solicitConn receives router solicitations along with the IPv6 control
+// messages required to validate them, which package ndp does not expose.
+type solicitConn struct {
+	pc  *ipv6.PacketConn
+	ifi *net.Interface
+	ip  net.IP
+}
+
+// listenSolicitations creates a solicitConn which receives router
+// solicitations sent to ip or the all-routers multicast group on ifi.
+func listenSolicitations(ifi *net.Interface, ip net.IP) (*solicitConn, error) {
+	ic, err := icmp.ListenPacket("ip6:ipv6-icmp", (&net.IPAddr{IP: ip, Zone: ifi.Name}).String())
+	if err != nil {
+		return nil, err
+	}
+
+	c := &solicitConn{
+		pc:  ic.IPv6PacketConn(),
+		ifi: ifi,
+		ip:  ip,
+	}
+
+	// We only want to accept router solicitation messages.
+	var f ipv6.ICMPFilter
+	f.SetAll(true)
+	f.Accept(ipv6.ICMPTypeRouterSolicitation)
+
+	if err := c.pc.SetICMPFilter(&f); err != nil {
+		_ = c.pc.Close()
+		return nil, fmt.Errorf("failed to apply ICMPv6 filter: %v", err)
+	}
+
+	// The hop limit is required to detect off-link solicitations.
+	if err := c.pc.SetControlMessage(ipv6.FlagHopLimit, true); err != nil {
+		_ = c.pc.Close()
+		return nil, fmt.Errorf("failed to request IPv6 control messages: %v", err)
+	}
+
+	// We are now a router.
+	if err := c.pc.JoinGroup(ifi, &net.IPAddr{IP: net.IPv6linklocalallrouters, Zone: ifi.Name}); err != nil {
+		_ = c.pc.Close()
+		return nil, fmt.Errorf("failed to join IPv6 link-local all routers multicast group: %v", err)
+	}
+
+	return c, nil
+}
+
+// Close leaves the all-routers multicast group and closes the solicitConn.
+func (c *solicitConn) Close() error {
+	if err := c.pc.LeaveGroup(c.ifi, &net.IPAddr{IP: net.IPv6linklocalallrouters, Zone: c.ifi.Name}); err != nil {
+		_ = c.pc.Close()
+		return fmt.Errorf("failed to leave IPv6 link-local all routers multicast group: %v", err)
+	}
+
+	return c.pc.Close()
+}
+
+// SetReadDeadline sets a deadline for the next router solicitation to arrive.
+func (c *solicitConn) SetReadDeadline(t time.Time) error {
+	return c.pc.SetReadDeadline(t)
+}
+
+// ReadFrom reads a single ICMPv6 message, its hop limit, and its source
+// address. Messages sent by this host are filtered. If no control message
+// is available, the hop limit is reported as 0.
+func (c *solicitConn) ReadFrom() ([]byte, int, net.IP, error) {
+	b := make([]byte, c.ifi.MTU)
+	for {
+		n, cm, src, err := c.pc.ReadFrom(b)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+
+		ip := src.(*net.IPAddr).IP
+		if ip.Equal(c.ip) {
+			continue
+		}
+
+		var hopLimit int
+		if cm != nil {
+			hopLimit = cm.HopLimit
+		}
+
+		return b[:n], hopLimit, ip, nil
+	}
+}
+
+// Reasons for which an invalid router solicitation is dropped.
+const (
+	invalidHopLimit  = "hop_limit"
+	invalidCode      = "code"
+	invalidLength    = "length"
+	invalidMalformed = "malformed"
+	invalidSourceLLA = "source_link_layer_address"
+)
+
+// checkSolicitation validates a router solicitation as described in:
+// https://tools.ietf.org/html/rfc4861#section-6.1.1.
+//
+// If the solicitation is invalid, it returns a reason for which it should be
+// dropped. The checksum is verified by the kernel.
+func checkSolicitation(b []byte, hopLimit int, src net.IP) (*ndp.RouterSolicitation, string) {
+	// The IP Hop Limit field must be 255 so the solicitation cannot have
+	// been forwarded by a router.
+	if hopLimit != ndp.HopLimit {
+		return nil, invalidHopLimit
+	}
+
+	// ICMP length, including the ICMP header, must be 8 or more octets.
+	if len(b) < 8 {
+		return nil, invalidLength
+	}
+
+	// ICMP Code must be 0.
+	if b[1] != 0 {
+		return nil, invalidCode
+	}
+
+	// All included options must have a length greater than zero, which
+	// package ndp enforces while parsing.
+	m, err := ndp.ParseMessage(b)
+	if err != nil {
+		return nil, invalidMalformed
+	}
+
+	rs, ok := m.(*ndp.RouterSolicitation)
+	if !ok {
+		return nil, invalidMalformed
+	}
+
+	// If the IP source address is the unspecified address, there must be no
+	// source link-layer address option in the message.
+	if src.Equal(net.IPv6unspecified) && sourceLLA(rs) != nil {
+		return nil, invalidSourceLLA
+	}
+
+	return rs, ""
+}
+
+// sourceLLA returns the source link-layer address from a router solicitation,
+// if one is present.
+func sourceLLA(rs *ndp.RouterSolicitation) net.HardwareAddr {
+	for _, o := range rs.Options {
+		if lla, ok := o.(*ndp.LinkLayerAddress); ok && lla.Direction == ndp.Source {
+			return lla.Addr
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/ndp"
+)
+
+func Test_checkSolicitation(t *testing.T) {
+	lla := &ndp.LinkLayerAddress{
+		Direction: ndp.Source,
+		Addr:      net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+	}
+
+	marshal := func(rs *ndp.RouterSolicitation) []byte {
+		b, err := ndp.MarshalMessage(rs)
+		if err != nil {
+			t.Fatalf("failed to marshal RS: %v", err)
+		}
+
+		return b
+	}
+
+	var (
+		rs     = &ndp.RouterSolicitation{Options: []ndp.Option{lla}}
+		b      = marshal(rs)
+		bare   = marshal(&ndp.RouterSolicitation{})
+		ll     = mustIP("fe80::1")
+		unspec = net.IPv6unspecified
+	)
+
+	code := make([]byte, len(b))
+	copy(code, b)
+	code[1] = 1
+
+	// Option with a length of zero.
+	zero := make([]byte, len(b))
+	copy(zero, b)
+	zero[len(b)-8+1] = 0
+
+	tests := []struct {
+		name     string
+		b        []byte
+		hopLimit int
+		src      net.IP
+		rs       *ndp.RouterSolicitation
+		reason   string
+	}{
+		{
+			name:     "hop limit",
+			b:        b,
+			hopLimit: 64,
+			src:      ll,
+			reason:   invalidHopLimit,
+		},
+		{
+			name:   "no hop limit",
+			b:      b,
+			src:    ll,
+			reason: invalidHopLimit,
+		},
+		{
+			name:     "length",
+			b:        b[:4],
+			hopLimit: ndp.HopLimit,
+			src:      ll,
+			reason:   invalidLength,
+		},
+		{
+			name:     "code",
+			b:        code,
+			hopLimit: ndp.HopLimit,
+			src:      ll,
+			reason:   invalidCode,
+		},
+		{
+			name:     "zero length option",
+			b:        zero,
+			hopLimit: ndp.HopLimit,
+			src:      ll,
+			reason:   invalidMalformed,
+		},
+		{
+			name:     "unspecified source with link-layer address",
+			b:        b,
+			hopLimit: ndp.HopLimit,
+			src:      unspec,
+			reason:   invalidSourceLLA,
+		},
+		{
+			name:     "OK unspecified source",
+			b:        bare,
+			hopLimit: ndp.HopLimit,
+			src:      unspec,
+			rs:       &ndp.RouterSolicitation{},
+		},
+		{
+			name:     "OK",
+			b:        b,
+			hopLimit: ndp.HopLimit,
+			src:      ll,
+			rs:       rs,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, reason := checkSolicitation(tt.b, tt.hopLimit, tt.src)
+			if diff := cmp.Diff(tt.reason, reason); diff != "" {
+				t.Fatalf("unexpected reason (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.rs, rs); diff != "" {
+				t.Fatalf("unexpected router solicitation (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
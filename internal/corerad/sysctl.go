@@ -0,0 +1,117 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mdlayher/corerad/internal/config"
+)
+
+// Keys of the IPv6 sysctls which may be managed for an interface.
+const (
+	sysctlForwarding     = "forwarding"
+	sysctlAcceptRA       = "accept_ra"
+	sysctlAcceptRADefrtr = "accept_ra_defrtr"
+	sysctlMTU            = "mtu"
+)
+
+// A managedSysctl is an IPv6 sysctl for an interface and its value.
+type managedSysctl struct {
+	Key   string
+	Value int
+}
+
+// managedSysctls returns the sysctls specified by s in the order in which
+// they are set.
+func managedSysctls(s config.Sysctls) []managedSysctl {
+	var ss []managedSysctl
+
+	// Forwarding is set first because changing it may affect the behavior of
+	// the other sysctls.
+	if s.Forwarding != nil {
+		ss = append(ss, managedSysctl{Key: sysctlForwarding, Value: boolInt(*s.Forwarding)})
+	}
+	if s.AcceptRA != nil {
+		ss = append(ss, managedSysctl{Key: sysctlAcceptRA, Value: *s.AcceptRA})
+	}
+	if s.AcceptRADefrtr != nil {
+		ss = append(ss, managedSysctl{Key: sysctlAcceptRADefrtr, Value: boolInt(*s.AcceptRADefrtr)})
+	}
+	if s.MTU != nil {
+		ss = append(ss, managedSysctl{Key: sysctlMTU, Value: *s.MTU})
+	}
+
+	return ss
+}
+
+// setSysctls sets each of ss on an interface and logs each change with logf.
+// It returns the previous values of the sysctls which changed, in the order
+// in which they should be restored. Sysctls which cannot be set due to
+// insufficient permissions are skipped. If any other error occurs, the
+// sysctls which were already changed are restored.
+func setSysctls(
+	iface string,
+	ss []managedSysctl,
+	logf func(format string, v ...interface{}),
+	mm *AdvertiserMetrics,
+) ([]managedSysctl, error) {
+	var prev []managedSysctl
+	rollback := func() {
+		if _, err := setSysctls(iface, prev, logf, mm); err != nil {
+			logf("failed to restore sysctls: %v", err)
+		}
+	}
+
+	for _, s := range ss {
+		v, err := getSysctl(iface, s.Key)
+		if err != nil {
+			rollback()
+			return nil, fmt.Errorf("failed to get sysctl %s: %v", s.Key, err)
+		}
+		if v == s.Value {
+			// Nothing to do.
+			continue
+		}
+
+		logf("sysctl net.ipv6.conf.%s.%s: %d -> %d", iface, s.Key, v, s.Value)
+
+		if err := setSysctl(iface, s.Key, s.Value); err != nil {
+			if errors.Is(err, os.ErrPermission) {
+				// Continue anyway but provide a hint.
+				logf("permission denied while setting sysctl %s, continuing anyway (try setting CAP_NET_ADMIN)", s.Key)
+				mm.ErrorsTotal.WithLabelValues(iface, "configuration").Inc()
+				continue
+			}
+
+			rollback()
+			return nil, fmt.Errorf("failed to set sysctl %s: %v", s.Key, err)
+		}
+
+		// Restore in the reverse order.
+		prev = append([]managedSysctl{{Key: s.Key, Value: v}}, prev...)
+	}
+
+	return prev, nil
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,36 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corerad
+
+// A watchKind is the kind of change reported by a watchEvent.
+type watchKind int
+
+// Possible watchKind values.
+const (
+	watchLink watchKind = iota
+	watchAddress
+	watchForwarding
+	watchResync
+)
+
+// A watchEvent reports that a network interface with index Index may have
+// changed in some way. Receivers must fetch the current state of the
+// interface to determine what changed.
+//
+// A watchResync event has no Index and reports that notifications were lost,
+// so any interface may have changed.
+type watchEvent struct {
+	Kind  watchKind
+	Index int
+}
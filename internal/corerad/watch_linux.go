@@ -0,0 +1,202 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package corerad
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Attributes of rtnetlink RTM_NEWNETCONF messages.
+const (
+	netconfaIfindex    = 1
+	netconfaForwarding = 2
+)
+
+// watchBufferSize is the size of the buffer used to receive rtnetlink
+// notifications.
+const watchBufferSize = 64 * 1024
+
+// watchInterfaces subscribes to rtnetlink notifications for link, IPv6
+// address, and IPv6 forwarding changes, and invokes fn for each change until
+// ctx is canceled. If notifications are lost, fn receives a watchResync event.
+func watchInterfaces(ctx context.Context, fn func(watchEvent)) error {
+	fd, err := unix.Socket(
+		unix.AF_NETLINK,
+		unix.SOCK_RAW|unix.SOCK_CLOEXEC|unix.SOCK_NONBLOCK,
+		unix.NETLINK_ROUTE,
+	)
+	if err != nil {
+		return os.NewSyscallError("socket", err)
+	}
+
+	sa := &unix.SockaddrNetlink{
+		Family: unix.AF_NETLINK,
+		Groups: 1<<(unix.RTNLGRP_LINK-1) |
+			1<<(unix.RTNLGRP_IPV6_IFADDR-1) |
+			1<<(unix.RTNLGRP_IPV6_NETCONF-1),
+	}
+	if err := unix.Bind(fd, sa); err != nil {
+		_ = unix.Close(fd)
+		return os.NewSyscallError("bind", err)
+	}
+
+	// The non-blocking file descriptor is registered with the runtime network
+	// poller, so reads can be interrupted by a deadline.
+	f := os.NewFile(uintptr(fd), "rtnetlink")
+	defer f.Close()
+
+	go func() {
+		<-ctx.Done()
+		_ = f.SetReadDeadline(deadlineNow)
+	}()
+
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	// The buffer is large enough for any single notification, but if one is
+	// truncated anyway or the kernel drops notifications because the socket's
+	// receive buffer overflowed, changes may have been missed. In that case,
+	// ask the caller to check all interfaces again and keep watching.
+	b := make([]byte, watchBufferSize)
+	for {
+		n, trunc, err := recvNetlink(rc, b)
+		switch {
+		case ctx.Err() != nil:
+			// Context canceled.
+			return nil
+		case errors.Is(err, unix.ENOBUFS), err == nil && trunc:
+			fn(watchEvent{Kind: watchResync})
+			continue
+		case err != nil:
+			return err
+		}
+
+		msgs, err := syscall.ParseNetlinkMessage(b[:n])
+		if err != nil {
+			return os.NewSyscallError("parsenetlinkmessage", err)
+		}
+
+		for _, m := range msgs {
+			if e, ok := parseWatchEvent(m); ok {
+				fn(e)
+			}
+		}
+	}
+}
+
+// recvNetlink receives a single netlink datagram into b and reports whether
+// it was truncated.
+func recvNetlink(rc syscall.RawConn, b []byte) (int, bool, error) {
+	var (
+		n, flags int
+		err      error
+	)
+
+	cerr := rc.Read(func(fd uintptr) bool {
+		n, _, flags, _, err = unix.Recvmsg(int(fd), b, nil, 0)
+		// Wait for the runtime network poller if no data is available.
+		return err != unix.EAGAIN
+	})
+	if cerr != nil {
+		return 0, false, cerr
+	}
+	if err != nil {
+		return 0, false, os.NewSyscallError("recvmsg", err)
+	}
+
+	return n, flags&unix.MSG_TRUNC != 0, nil
+}
+
+// parseWatchEvent parses a watchEvent from an rtnetlink notification.
+func parseWatchEvent(m syscall.NetlinkMessage) (watchEvent, bool) {
+	switch m.Header.Type {
+	case unix.RTM_NEWLINK, unix.RTM_DELLINK:
+		if len(m.Data) < unix.SizeofIfInfomsg {
+			return watchEvent{}, false
+		}
+
+		ifim := (*syscall.IfInfomsg)(unsafe.Pointer(&m.Data[0]))
+		return watchEvent{Kind: watchLink, Index: int(ifim.Index)}, true
+	case unix.RTM_NEWADDR, unix.RTM_DELADDR:
+		if len(m.Data) < unix.SizeofIfAddrmsg {
+			return watchEvent{}, false
+		}
+
+		ifam := (*syscall.IfAddrmsg)(unsafe.Pointer(&m.Data[0]))
+		return watchEvent{Kind: watchAddress, Index: int(ifam.Index)}, true
+	case unix.RTM_NEWNETCONF:
+		return parseNetconf(m.Data)
+	}
+
+	return watchEvent{}, false
+}
+
+// parseNetconf parses a watchEvent from the body of an RTM_NEWNETCONF
+// message which reports a change to an interface's forwarding state.
+func parseNetconf(b []byte) (watchEvent, bool) {
+	// Skip the 1 byte netconfmsg header and its padding.
+	const hdrLen = 4
+	if len(b) < hdrLen {
+		return watchEvent{}, false
+	}
+	b = b[hdrLen:]
+
+	var (
+		index      int
+		forwarding bool
+	)
+
+	for len(b) >= unix.SizeofRtAttr {
+		rta := (*unix.RtAttr)(unsafe.Pointer(&b[0]))
+		l := int(rta.Len)
+		if l < unix.SizeofRtAttr || l > len(b) {
+			return watchEvent{}, false
+		}
+
+		v := b[unix.SizeofRtAttr:l]
+		switch rta.Type {
+		case netconfaIfindex:
+			if len(v) == 4 {
+				index = int(*(*int32)(unsafe.Pointer(&v[0])))
+			}
+		case netconfaForwarding:
+			forwarding = true
+		}
+
+		// Attributes are aligned to 4 bytes.
+		l = (l + unix.RTA_ALIGNTO - 1) &^ (unix.RTA_ALIGNTO - 1)
+		if l > len(b) {
+			break
+		}
+		b = b[l:]
+	}
+
+	// Only report forwarding changes for a specific interface, rather than
+	// for the "all" or "default" configuration.
+	if !forwarding || index <= 0 {
+		return watchEvent{}, false
+	}
+
+	return watchEvent{Kind: watchForwarding, Index: index}, true
+}
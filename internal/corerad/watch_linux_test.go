@@ -0,0 +1,240 @@
+// Copyright 2019 Matt Layher
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package corerad
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sys/unix"
+)
+
+func TestWatchInterfacesLinuxResync(t *testing.T) {
+	skipUnprivileged(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Block on the first event so notifications pile up in the socket's
+	// receive buffer until the kernel drops them.
+	var (
+		blockC  = make(chan struct{})
+		resyncC = make(chan struct{}, 1)
+		linkC   = make(chan int, 1024)
+		first   = true
+		resync  bool
+	)
+
+	var eg errgroup.Group
+	eg.Go(func() error {
+		return watchInterfaces(ctx, func(e watchEvent) {
+			if first {
+				first = false
+				<-blockC
+			}
+
+			switch e.Kind {
+			case watchLink:
+				// Only record link events which occur after the resync.
+				if !resync {
+					return
+				}
+
+				select {
+				case linkC <- e.Index:
+				default:
+				}
+			case watchResync:
+				resync = true
+				select {
+				case resyncC <- struct{}{}:
+				default:
+				}
+			}
+		})
+	})
+
+	f, err := ioutil.TempFile("", "corerad-watch")
+	if err != nil {
+		t.Fatalf("failed to create batch file: %v", err)
+	}
+	defer os.Remove(f.Name())
+
+	for i := 0; i < 256; i++ {
+		fmt.Fprintf(f, "link add cradwatch%d type veth peer name cradwatchp%d\n", i, i)
+		fmt.Fprintf(f, "link del cradwatch%d\n", i)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("failed to close batch file: %v", err)
+	}
+
+	shell(t, "ip", "-batch", f.Name())
+	close(blockC)
+
+	select {
+	case <-resyncC:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for resync event")
+	}
+
+	// The watch must continue after notifications are lost.
+	const ifName = "cradwatchlast"
+	shell(t, "ip", "tuntap", "add", ifName, "mode", "tun")
+	defer shell(t, "ip", "link", "del", ifName)
+
+	ifi, err := net.InterfaceByName(ifName)
+	if err != nil {
+		t.Fatalf("failed to look up interface: %v", err)
+	}
+
+	timeout := time.After(5 * time.Second)
+	for index := 0; index != ifi.Index; {
+		select {
+		case index = <-linkC:
+		case <-timeout:
+			t.Fatal("timed out waiting for link event after resync")
+		}
+	}
+
+	cancel()
+	if err := eg.Wait(); err != nil {
+		t.Fatalf("failed to watch interfaces: %v", err)
+	}
+}
+
+func Test_parseNetconf(t *testing.T) {
+	t.Parallel()
+
+	// The netconfmsg header: family AF_INET6 and padding.
+	hdr := []byte{unix.AF_INET6, 0x00, 0x00, 0x00}
+
+	var (
+		index      = rtattr(netconfaIfindex, int32Bytes(3))
+		forwarding = rtattr(netconfaForwarding, int32Bytes(1))
+	)
+
+	tests := []struct {
+		name string
+		b    []byte
+		e    watchEvent
+		ok   bool
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "truncated header",
+			b:    hdr[:2],
+		},
+		{
+			name: "no attributes",
+			b:    hdr,
+		},
+		{
+			name: "bad attribute length short",
+			b:    concat(hdr, []byte{0x02, 0x00, netconfaIfindex, 0x00}, forwarding),
+		},
+		{
+			name: "bad attribute length long",
+			b:    concat(hdr, forwarding, []byte{0xff, 0x00, netconfaIfindex, 0x00, 0x03, 0x00, 0x00, 0x00}),
+		},
+		{
+			name: "all interfaces",
+			b:    concat(hdr, rtattr(netconfaIfindex, int32Bytes(-1)), forwarding),
+		},
+		{
+			name: "default interface",
+			b:    concat(hdr, rtattr(netconfaIfindex, int32Bytes(-2)), forwarding),
+		},
+		{
+			name: "no index",
+			b:    concat(hdr, forwarding),
+		},
+		{
+			name: "no forwarding",
+			b:    concat(hdr, index),
+		},
+		{
+			name: "OK",
+			b:    concat(hdr, index, forwarding),
+			e:    watchEvent{Kind: watchForwarding, Index: 3},
+			ok:   true,
+		},
+		{
+			name: "OK other attributes",
+			b: concat(
+				hdr,
+				// NETCONFA_MC_FORWARDING.
+				rtattr(3, int32Bytes(0)),
+				forwarding,
+				index,
+			),
+			e:  watchEvent{Kind: watchForwarding, Index: 3},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := parseNetconf(tt.b)
+			if ok != tt.ok {
+				t.Fatalf("unexpected ok value: %v", ok)
+			}
+
+			if diff := cmp.Diff(tt.e, e); diff != "" {
+				t.Fatalf("unexpected event (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// rtattr encodes a route attribute with type typ and value v, padded to the
+// attribute alignment.
+func rtattr(typ uint16, v []byte) []byte {
+	l := unix.SizeofRtAttr + len(v)
+	b := make([]byte, (l+unix.RTA_ALIGNTO-1)&^(unix.RTA_ALIGNTO-1))
+
+	rta := (*unix.RtAttr)(unsafe.Pointer(&b[0]))
+	rta.Len = uint16(l)
+	rta.Type = typ
+	copy(b[unix.SizeofRtAttr:], v)
+
+	return b
+}
+
+// int32Bytes encodes v in native byte order.
+func int32Bytes(v int32) []byte {
+	b := make([]byte, 4)
+	*(*int32)(unsafe.Pointer(&b[0])) = v
+	return b
+}
+
+func concat(bs ...[]byte) []byte {
+	var out []byte
+	for _, b := range bs {
+		out = append(out, b...)
+	}
+
+	return out
+}